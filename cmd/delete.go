@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"dup/pkg/manage"
+
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+)
+
+func NewDeleteCmd(f cmdutil.Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
+	o := manage.NewDeleteOptions(ioStreams)
+
+	cmd := &cobra.Command{
+		Use:   "delete [duplicate-name | --all-mine | --source KIND/NAME]",
+		Short: "Delete duplicates together with everything created alongside them",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(o.Complete(f, args, cmd))
+			cmdutil.CheckErr(o.Validate())
+			cmdutil.CheckErr(o.Run())
+		},
+	}
+
+	cmd.Flags().BoolVar(&o.AllMine, "all-mine", o.AllMine, "Delete every duplicate created by the current user")
+	cmd.Flags().StringVar(&o.Source, "source", o.Source, "Delete every duplicate of the given source, as NAME or KIND/NAME")
+	cmd.Flags().StringVar(&o.Cascade, "cascade", o.Cascade, "Must be \"background\", \"orphan\", or \"foreground\". Selects the deletion cascading strategy for the dependents (e.g. Pods created by a duplicated Deployment).")
+	cmd.Flags().BoolVar(&o.DeletePVCs, "delete-pvcs", o.DeletePVCs, "Delete persistent volume claims provisioned by dup without asking for confirmation")
+	cmdutil.AddDryRunFlag(cmd)
+	return cmd
+}
@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"dup/pkg/manage"
+
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+)
+
+func NewDiffCmd(f cmdutil.Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
+	o := manage.NewDiffOptions(ioStreams)
+
+	cmd := &cobra.Command{
+		Use:   "diff <duplicate-name|KIND/NAME>",
+		Short: "Show the changes between a duplicate and its source",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(o.Complete(f, args, cmd))
+			cmdutil.CheckErr(o.Validate())
+			cmdutil.CheckErr(o.Run())
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.Output, "output", "o", o.Output, "Output format. Empty for a unified diff, or json-patch for RFC 6902 operations turning the source into the duplicate.")
+	cmd.Flags().StringVar(&o.Color, "color", o.Color, "Color the unified diff. One of: auto, always, never.")
+	return cmd
+}
@@ -0,0 +1,24 @@
+package cmd
+
+import (
+	"dup/pkg/manage"
+
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+)
+
+func NewExtendCmd(f cmdutil.Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
+	o := manage.NewExtendOptions(ioStreams)
+
+	cmd := &cobra.Command{
+		Use:   "extend <duplicate-name> <duration>",
+		Short: "Push the expiry of a duplicate out by the given duration",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(o.Complete(f, args, cmd))
+			cmdutil.CheckErr(o.Run())
+		},
+	}
+	return cmd
+}
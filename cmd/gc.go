@@ -0,0 +1,27 @@
+package cmd
+
+import (
+	"dup/pkg/manage"
+
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+)
+
+func NewGCCmd(f cmdutil.Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
+	o := manage.NewGCOptions(ioStreams)
+
+	cmd := &cobra.Command{
+		Use:   "gc",
+		Short: "Delete duplicates whose TTL has expired",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(o.Complete(f, args, cmd))
+			cmdutil.CheckErr(o.Run())
+		},
+	}
+
+	cmd.Flags().BoolVarP(&o.AllNamespaces, "all-namespaces", "A", o.AllNamespaces, "Look for expired duplicates across all namespaces, set to false to only use the current namespace")
+	cmdutil.AddDryRunFlag(cmd)
+	return cmd
+}
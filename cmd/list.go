@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"dup/pkg/manage"
+
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+)
+
+func NewListCmd(f cmdutil.Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
+	o := manage.NewListOptions(ioStreams)
+
+	cmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List duplicates created by dup",
+		Args:    cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(o.Complete(f, args, cmd))
+			cmdutil.CheckErr(o.Run())
+		},
+	}
+
+	cmd.Flags().BoolVarP(&o.AllNamespaces, "all-namespaces", "A", o.AllNamespaces, "List duplicates across all namespaces")
+	cmd.Flags().BoolVar(&o.Mine, "mine", o.Mine, "Only list duplicates created by the current user")
+	cmd.Flags().StringVar(&o.Source, "source", o.Source, "Only list duplicates of the given source, as NAME or KIND/NAME")
+	o.PrintFlags.AddFlags(cmd)
+	cmd.Flags().Lookup("output").Usage = fmt.Sprintf("Output format. One of: (%s).", strings.Join(append([]string{"wide"}, o.PrintFlags.AllowedFormats()...), ", "))
+	return cmd
+}
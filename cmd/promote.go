@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"dup/pkg/manage"
+
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+)
+
+func NewPromoteCmd(f cmdutil.Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
+	o := manage.NewPromoteOptions(ioStreams)
+
+	cmd := &cobra.Command{
+		Use:   "promote <duplicate-name|KIND/NAME>",
+		Short: "Apply the changes made on a duplicate back onto its source",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(o.Complete(f, args, cmd))
+			cmdutil.CheckErr(o.Validate())
+			cmdutil.CheckErr(o.Run())
+		},
+	}
+
+	cmd.Flags().StringVar(&o.Type, "type", o.Type, "How the changes are applied to the source. One of: strategic, server-side.")
+	cmd.Flags().BoolVar(&o.Edit, "edit", o.Edit, "Open the patch in an editor before applying it")
+	cmd.Flags().StringVar(&o.FieldManager, "field-manager", o.FieldManager, "Name of the manager used to track field ownership")
+	cmd.Flags().BoolVar(&o.ForceConflicts, "force-conflicts", o.ForceConflicts, "With --type=server-side, take ownership of fields owned by other managers")
+	cmd.Flags().StringVar(&o.Color, "color", o.Color, "Color the diff. One of: auto, always, never.")
+	cmdutil.AddDryRunFlag(cmd)
+	return cmd
+}
@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"dup/pkg/manage"
+
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+)
+
+func NewResumeCmd(f cmdutil.Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
+	o := manage.NewResumeOptions(ioStreams)
+	o.Execute = func(args []string) error {
+		root := NewRootCmd(ioStreams)
+		root.SetArgs(args)
+		return root.Execute()
+	}
+
+	cmd := &cobra.Command{
+		Use:   "resume [file]",
+		Short: "Edit a failed or cancelled duplication again, from the file its changes were preserved to (the most recent one by default)",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(o.Complete(f, args, cmd))
+			cmdutil.CheckErr(o.Run())
+		},
+	}
+	return cmd
+}
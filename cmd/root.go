@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"dup/pkg/editor"
+	"dup/pkg/session"
 	"dup/pkg/util"
 
 	"github.com/spf13/cobra"
@@ -39,10 +40,11 @@ func NewRootCmd(ioStreams genericclioptions.IOStreams) *cobra.Command {
 	f := cmdutil.NewFactory(matchVersionKubeConfigFlags)
 
 	var rootCmd = &cobra.Command{
-		Use:               "kubectl dup [options] <resource-type, ...resource-type-n> <resource> [output-resource-prefix]",
+		Use:               "kubectl dup [options] <resource-type, ...resource-type-n> <resource>",
 		Short:             "Duplicate a pod out of a Deployment",
 		ValidArgsFunction: completion.ResourceTypeAndNameCompletionFunc(f),
-		Args:              cobra.RangeArgs(2, 3),
+		Args:              cobra.ExactArgs(2),
+		Annotations:       map[string]string{cobra.CommandDisplayNameAnnotation: "kubectl dup"},
 		Run: func(cmd *cobra.Command, args []string) {
 			cmdutil.CheckErr(o.Complete(f, args, cmd))
 			cmdutil.CheckErr(o.Run())
@@ -52,14 +54,53 @@ func NewRootCmd(ioStreams genericclioptions.IOStreams) *cobra.Command {
 	rootCmd.Flags().BoolVarP(&o.DuplicateOptions.DuplicateInnerPod, "pod", "p", false, "Duplicate pod of resource, currently only applies for: 'StatefulSet','Deployment','CronJob','Job'")
 	rootCmd.Flags().BoolVarP(&o.DuplicateOptions.DisableProbes, "disable-probes", "d", true, "Disable Readiness and liveness probes for duplicated pods only (requires '-p' for complex resources)")
 	rootCmd.Flags().BoolVarP(&o.DuplicateOptions.LoopCommand, "command-loop", "l", false, "Changes running command to an infinite loop (currently : \"tail -f /dev/null\"")
+	rootCmd.Flags().StringVar(&o.Profile, "profile", "", "Use the options of the named profile of the config file, flags given explicitly take precedence (see 'kubectl dup profiles')")
+	rootCmd.Flags().StringArrayVar(&o.MutateOptions.Set, "set", nil, "Set a field of every duplicate before editing, e.g. spec.template.spec.containers[name=app].env[name=X].value=1 (repeatable)")
+	rootCmd.Flags().StringArrayVar(&o.MutateOptions.Patches, "patch", nil, "Patch every duplicate before editing, with a patch of --patch-type in YAML or JSON (repeatable)")
+	rootCmd.Flags().StringArrayVar(&o.MutateOptions.PatchFiles, "patch-file", nil, "Patch every duplicate before editing with the patch in the given file (repeatable)")
+	rootCmd.Flags().StringVar(&o.MutateOptions.PatchType, "patch-type", o.MutateOptions.PatchType, "The type of --patch and --patch-file. One of: strategic, merge, json.")
+	rootCmd.Flags().StringArrayVar(&o.MutateOptions.Transforms, "transform", nil, "Run the transform(obj, ctx) function of the given Starlark script over every duplicate before editing, after the transforms of the config file (repeatable)")
+	rootCmd.Flags().StringArrayVar(&o.MutateOptions.Fn, "fn", nil, "Pipe the duplicates through the KRM function at the given path, with its arguments, before editing, after the functions of the config file (repeatable)")
+	rootCmd.Flags().BoolVar(&o.Interactive, "interactive", false, "Pick containers, probes, env, resources and volumes of the duplicated pods in menus before the editor opens (instead of it with -k)")
+	rootCmd.Flags().BoolVar(&o.Minimal, "minimal", false, "Hide fields equal to their API defaults and server populated metadata in the editor, the defaults are put back once edited")
+	rootCmd.Flags().BoolVar(&o.SideBySide, "side-by-side", false, "Open the objects being duplicated read-only next to the duplicates, in the diff mode of editors having one")
+	rootCmd.Flags().StringVar(&o.ResumeFile, "resume-file", "", "Edit the given preserved file again instead of duplicating, see 'kubectl dup resume'")
+	rootCmd.Flags().MarkHidden("resume-file")
 	rootCmd.Flags().BoolVarP(&o.SkipEdit, "skip-edit", "k", false, "Skip editing duplicated resource before creation")
+	rootCmd.Flags().BoolVar(&o.EditSeparately, "edit-separately", false, "Open one editor session per duplicated object instead of a single List holding all of them")
+	rootCmd.Flags().DurationVar(&o.DuplicateOptions.TTL, "ttl", 0, "Expire duplicates after the given duration (e.g. 4h), expired duplicates are removed by 'kubectl dup gc'")
+	rootCmd.Flags().BoolVar(&o.SessionOptions.Wait, "wait", false, "Wait for the duplicated pod to become ready, streaming its events and readiness progress")
+	rootCmd.Flags().StringVar(&o.SessionOptions.Exec, "exec", "", "Once the duplicated pod is ready, exec the `CMD` given as --exec=CMD in it, or open its shell without a command")
+	rootCmd.Flags().Lookup("exec").NoOptDefVal = session.ExecShell
+	rootCmd.Flags().BoolVar(&o.SessionOptions.Attach, "attach", false, "Once the duplicated pod is ready, attach to its container")
+	rootCmd.Flags().BoolVar(&o.SessionOptions.Logs, "logs", false, "Once the duplicated pod is ready, follow the logs of its container")
+	rootCmd.Flags().StringArrayVar(&o.SessionOptions.PortForward, "port-forward", nil, "Once the duplicated pod is ready, forward LOCAL:REMOTE ports to it until interrupted, 'auto' forwards every container port to a free local port (repeatable)")
+	rootCmd.Flags().BoolVar(&o.SessionOptions.Remove, "rm", false, "Delete everything created when the --exec, --attach or --port-forward session ends or is interrupted (implies --ttl 12h unless set)")
+	rootCmd.Flags().StringVarP(&o.SessionOptions.Container, "container", "c", "", "Container to use with --exec, --attach and --logs, defaults to the pod's default container")
+	rootCmd.Flags().DurationVar(&o.SessionOptions.WaitTimeout, "wait-timeout", o.SessionOptions.WaitTimeout, "How long to wait for the duplicated pod to become ready")
 	rootCmd.Flags().BoolVar(&o.WindowsLineEndings, "windows-line-endings", o.WindowsLineEndings,
 		"Defaults to the line ending native to your platform.")
 
-	kubeConfigFlags.AddFlags(rootCmd.Flags())
-	matchVersionKubeConfigFlags.AddFlags(rootCmd.Flags())
+	kubeConfigFlags.AddFlags(rootCmd.PersistentFlags())
+	matchVersionKubeConfigFlags.AddFlags(rootCmd.PersistentFlags())
 	cmdutil.AddValidateFlags(rootCmd)
+	cmdutil.AddDryRunFlag(rootCmd)
+	rootCmd.Flags().StringVar(&o.DuplicateOptions.Name, "name", "", "Name of the duplicate instead of a generated one, running again with --server-side updates it (single object only)")
+	rootCmd.Flags().BoolVar(&o.ServerSide, "server-side", false, "Create the duplicates through server-side apply, a duplicate named like an existing one while editing updates it instead of failing")
+	rootCmd.Flags().StringVar(&o.FieldManager, "field-manager", o.FieldManager, "Name of the manager used to track field ownership")
+	rootCmd.Flags().StringVar(&o.EmitKustomize, "emit-kustomize", "", "Write a kustomize overlay to the given directory instead of creating the duplicates, with the sources as its base and the changes as patches, images and replicas")
+	rootCmd.Flags().StringVar(&o.OutputDir, "output-dir", "", "Write the final manifests to files in the given directory instead of creating them, in the format of -o (yaml by default)")
 	o.PrintFlags.AddFlags(rootCmd)
+
+	rootCmd.AddCommand(NewGCCmd(f, ioStreams))
+	rootCmd.AddCommand(NewExtendCmd(f, ioStreams))
+	rootCmd.AddCommand(NewListCmd(f, ioStreams))
+	rootCmd.AddCommand(NewDeleteCmd(f, ioStreams))
+	rootCmd.AddCommand(NewDiffCmd(f, ioStreams))
+	rootCmd.AddCommand(NewPromoteCmd(f, ioStreams))
+	rootCmd.AddCommand(NewSyncCmd(f, ioStreams))
+	rootCmd.AddCommand(NewProfilesCmd(f, ioStreams))
+	rootCmd.AddCommand(NewResumeCmd(f, ioStreams))
 	return rootCmd
 }
 
@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"dup/pkg/manage"
+
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+)
+
+func NewSyncCmd(f cmdutil.Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
+	o := manage.NewSyncOptions(ioStreams)
+
+	cmd := &cobra.Command{
+		Use:   "sync <duplicate-name|KIND/NAME>",
+		Short: "Bring later changes of the source into a duplicate",
+		Long: `Reapply the current pod template of the source to a duplicate. Changes made to
+the duplicate since it was created, and the mutations applied by dup, are kept.
+Fields changed both on the duplicate and on the source are reported as conflicts.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(o.Complete(f, args, cmd))
+			cmdutil.CheckErr(o.Validate())
+			cmdutil.CheckErr(o.Run())
+		},
+	}
+
+	cmd.Flags().BoolVarP(&o.Follow, "follow", "f", o.Follow, "Keep watching the source and sync the duplicate after each change")
+	cmd.Flags().BoolVar(&o.Overwrite, "overwrite", o.Overwrite, "Resolve conflicts by taking the values of the source")
+	cmd.Flags().StringVar(&o.Color, "color", o.Color, "Color the diff. One of: auto, always, never.")
+	cmdutil.AddDryRunFlag(cmd)
+	return cmd
+}
@@ -0,0 +1,235 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"dup/pkg/mutate"
+
+	"sigs.k8s.io/yaml"
+)
+
+// RepoFile is the name of the per-repository config file, looked up from the
+// working directory up to the root of the repository.
+const RepoFile = ".kubectl-dup.yaml"
+
+// Config holds the settings of ~/.config/kubectl-dup/config.yaml, overridden
+// by the RepoFile of the current repository.
+type Config struct {
+	// Transforms are Starlark scripts run over every duplicate, relative
+	// paths are resolved against the directory of the config file.
+	Transforms []string `json:"transforms,omitempty"`
+	// Functions are KRM functions run over the duplicates, executables with a
+	// relative path are resolved against the directory of the config file.
+	Functions []mutate.Function `json:"functions,omitempty"`
+	// Profiles are named sets of options, selected with --profile
+	Profiles map[string]*Profile `json:"profiles,omitempty"`
+	// DiffEditors hold the arguments laying out the original and the
+	// duplicate for --side-by-side, keyed by editor command name.
+	DiffEditors map[string]string `json:"diffEditors,omitempty"`
+	// TrustedRepos lists the directories whose RepoFile may run transforms,
+	// functions and diff editors without --profile. It is only read from the
+	// user config file.
+	TrustedRepos []string `json:"trustedRepos,omitempty"`
+
+	// Untrusted is the RepoFile whose transforms, functions, diff editors or
+	// profiles were partly ignored because its directory isn't trusted
+	Untrusted string `json:"-"`
+
+	// Files lists the config files that were read, in the order they apply
+	Files []string `json:"-"`
+	path  string
+}
+
+// Path returns the location of the user config file, in $XDG_CONFIG_HOME or ~/.config
+func Path() (string, error) {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if len(dir) == 0 {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "kubectl-dup", "config.yaml"), nil
+}
+
+// Load reads the user config file and the one of the current repository,
+// missing files are empty configs. Profiles of the repository replace those
+// of the same name, they only apply when chosen with --profile. Unless its
+// directory is one of the TrustedRepos, the transforms, functions and diff
+// editors of the repository are ignored, as are the transforms, functions and
+// patch files of its profiles, and its profiles don't replace the user ones.
+// Trusted transforms and functions run after the user ones.
+func Load() (*Config, error) {
+	path, err := Path()
+	if err != nil {
+		return nil, err
+	}
+	c, err := loadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	repoPath, err := findRepoFile()
+	if err != nil || len(repoPath) == 0 || repoPath == path {
+		return c, err
+	}
+	repo, err := loadFile(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	if !c.trusts(repoPath) && repo.distrust(c) {
+		c.Untrusted = repoPath
+	}
+	c.merge(repo)
+	return c, nil
+}
+
+// Profile returns the profile called name
+func (c *Config) Profile(name string) (*Profile, error) {
+	p, ok := c.Profiles[name]
+	if !ok {
+		if len(c.Files) == 0 {
+			return nil, fmt.Errorf("profile %q not found, no config file exists", name)
+		}
+		return nil, fmt.Errorf("profile %q not found in %s", name, strings.Join(c.Files, ", "))
+	}
+	return p, nil
+}
+
+// trusts returns whether repoPath is in one of the TrustedRepos, relative
+// entries are resolved against the directory of the config file and ~ is
+// the home directory.
+func (c *Config) trusts(repoPath string) bool {
+	dir := filepath.Dir(repoPath)
+	for _, trusted := range c.TrustedRepos {
+		if rest, ok := strings.CutPrefix(trusted, "~/"); ok {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				continue
+			}
+			trusted = filepath.Join(home, rest)
+		}
+		if filepath.Clean(c.resolve(trusted)) == dir {
+			return true
+		}
+	}
+	return false
+}
+
+// distrust drops from an untrusted repository config what could run code or
+// read local files, and its profiles named like one of user, returning whether
+// anything was dropped.
+func (c *Config) distrust(user *Config) bool {
+	dropped := len(c.Transforms) > 0 || len(c.Functions) > 0 || len(c.DiffEditors) > 0
+	c.Transforms, c.Functions, c.DiffEditors = nil, nil, nil
+	for name, p := range c.Profiles {
+		if _, ok := user.Profiles[name]; ok {
+			delete(c.Profiles, name)
+			dropped = true
+			continue
+		}
+		if len(p.Transforms) > 0 || len(p.Functions) > 0 || len(p.PatchFiles) > 0 {
+			dropped = true
+		}
+		p.Transforms, p.Functions, p.PatchFiles = nil, nil, nil
+	}
+	return dropped
+}
+
+func (c *Config) merge(other *Config) {
+	c.Transforms = append(c.Transforms, other.Transforms...)
+	c.Functions = append(c.Functions, other.Functions...)
+	if c.Profiles == nil {
+		c.Profiles = map[string]*Profile{}
+	}
+	for name, p := range other.Profiles {
+		c.Profiles[name] = p
+	}
+	if c.DiffEditors == nil {
+		c.DiffEditors = map[string]string{}
+	}
+	for name, template := range other.DiffEditors {
+		c.DiffEditors[name] = template
+	}
+	c.Files = append(c.Files, other.Files...)
+}
+
+// findRepoFile returns the RepoFile closest to the working directory, without
+// looking above the root of a git repository.
+func findRepoFile() (string, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	for {
+		path := filepath.Join(dir, RepoFile)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return "", nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+func loadFile(path string) (*Config, error) {
+	c := &Config{path: path}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.UnmarshalStrict(data, c); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %v", path, err)
+	}
+	c.Files = []string{path}
+	c.Transforms = c.resolveAll(c.Transforms)
+	c.Functions = c.resolveFunctions(c.Functions)
+	for name, p := range c.Profiles {
+		if p == nil {
+			p = &Profile{}
+			c.Profiles[name] = p
+		}
+		if err := p.complete(c, name); err != nil {
+			return nil, fmt.Errorf("invalid config file %s: %v", path, err)
+		}
+	}
+	return c, nil
+}
+
+// resolve returns path relative to the directory of the config file
+func (c *Config) resolve(path string) string {
+	if filepath.IsAbs(path) || len(c.path) == 0 {
+		return path
+	}
+	return filepath.Join(filepath.Dir(c.path), path)
+}
+
+func (c *Config) resolveAll(paths []string) []string {
+	for i, path := range paths {
+		paths[i] = c.resolve(path)
+	}
+	return paths
+}
+
+func (c *Config) resolveFunctions(functions []mutate.Function) []mutate.Function {
+	for i, fn := range functions {
+		// executables without a directory are looked up in PATH
+		if strings.ContainsRune(fn.Exec, filepath.Separator) {
+			functions[i].Exec = c.resolve(fn.Exec)
+		}
+	}
+	return functions
+}
@@ -0,0 +1,229 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"dup/pkg/mutate"
+)
+
+// writeFile writes data to path, creating its directory
+func writeFile(t *testing.T, path, data string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// chdir changes the working directory for the duration of the test
+func chdir(t *testing.T, dir string) {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+}
+
+const userConfig = `
+transforms: [user.star]
+profiles:
+  debug:
+    description: user debug
+    set: [spec.replicas=1]
+`
+
+const repoConfig = `
+transforms: [repo.star]
+functions:
+- exec: ./fn.sh
+diffEditors:
+  code: --diff {{.Original}} {{.Duplicate}}
+profiles:
+  debug:
+    description: repo debug
+  team:
+    description: team
+    set: [spec.replicas=2]
+    patchFiles: [team.yaml]
+    transforms: [team.star]
+    functions:
+    - exec: ./team.sh
+`
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name string
+		// trusted lists the trustedRepos of the user config
+		trusted string
+		want    func(configDir, repo string) *Config
+	}{
+		{
+			name: "untrusted repository",
+			want: func(configDir, repo string) *Config {
+				return &Config{
+					Transforms: []string{filepath.Join(configDir, "user.star")},
+					Profiles: map[string]*Profile{
+						"debug": {Description: "user debug", Set: []string{"spec.replicas=1"}, Name: "debug", File: filepath.Join(configDir, "config.yaml")},
+						"team":  {Description: "team", Set: []string{"spec.replicas=2"}, Name: "team", File: filepath.Join(repo, RepoFile)},
+					},
+					DiffEditors: map[string]string{},
+					Untrusted:   filepath.Join(repo, RepoFile),
+					Files:       []string{filepath.Join(configDir, "config.yaml"), filepath.Join(repo, RepoFile)},
+				}
+			},
+		},
+		{
+			name:    "trusted repository",
+			trusted: "trustedRepos: [../repo]\n",
+			want: func(configDir, repo string) *Config {
+				return &Config{
+					Transforms: []string{filepath.Join(configDir, "user.star"), filepath.Join(repo, "repo.star")},
+					Functions:  []mutate.Function{{Exec: filepath.Join(repo, "fn.sh")}},
+					Profiles: map[string]*Profile{
+						"debug": {Description: "repo debug", Name: "debug", File: filepath.Join(repo, RepoFile)},
+						"team": {
+							Description: "team",
+							Set:         []string{"spec.replicas=2"},
+							PatchFiles:  []string{filepath.Join(repo, "team.yaml")},
+							Transforms:  []string{filepath.Join(repo, "team.star")},
+							Functions:   []mutate.Function{{Exec: filepath.Join(repo, "team.sh")}},
+							Name:        "team",
+							File:        filepath.Join(repo, RepoFile),
+						},
+					},
+					DiffEditors:  map[string]string{"code": "--diff {{.Original}} {{.Duplicate}}"},
+					TrustedRepos: []string{"../repo"},
+					Files:        []string{filepath.Join(configDir, "config.yaml"), filepath.Join(repo, RepoFile)},
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			t.Setenv("XDG_CONFIG_HOME", root)
+			configDir := filepath.Join(root, "kubectl-dup")
+			repo := filepath.Join(root, "repo")
+			writeFile(t, filepath.Join(configDir, "config.yaml"), userConfig+tt.trusted)
+			writeFile(t, filepath.Join(repo, RepoFile), repoConfig)
+			if err := os.Mkdir(filepath.Join(repo, ".git"), 0o755); err != nil {
+				t.Fatal(err)
+			}
+			// the repository file is found from a subdirectory
+			sub := filepath.Join(repo, "deploy")
+			if err := os.Mkdir(sub, 0o755); err != nil {
+				t.Fatal(err)
+			}
+			chdir(t, sub)
+
+			c, err := Load()
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := tt.want(configDir, repo)
+			want.path = filepath.Join(configDir, "config.yaml")
+			if !reflect.DeepEqual(c, want) {
+				t.Errorf("expected\n%+v\ngot\n%+v", want, c)
+			}
+		})
+	}
+}
+
+func TestLoadWithoutFiles(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", root)
+	if err := os.Mkdir(filepath.Join(root, ".git"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	chdir(t, root)
+	c, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Files) > 0 || len(c.Profiles) > 0 || len(c.Untrusted) > 0 {
+		t.Errorf("expected an empty config, got %+v", c)
+	}
+	if _, err := c.Profile("debug"); err == nil {
+		t.Errorf("expected an error for a missing profile")
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", root)
+	chdir(t, root)
+	tests := []string{
+		"unknown: true\n",
+		"profiles:\n  debug:\n    ttl: soon\n",
+	}
+	for _, data := range tests {
+		writeFile(t, filepath.Join(root, "kubectl-dup", "config.yaml"), data)
+		if _, err := Load(); err == nil {
+			t.Errorf("%q: expected an error", data)
+		}
+	}
+}
+
+func TestTrusts(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	c := &Config{path: "/home/me/.config/kubectl-dup/config.yaml"}
+	tests := []struct {
+		trusted  string
+		repoPath string
+		want     bool
+	}{
+		{trusted: "/src/manifests", repoPath: "/src/manifests/" + RepoFile, want: true},
+		{trusted: "/src/manifests/", repoPath: "/src/manifests/" + RepoFile, want: true},
+		{trusted: "/src/manifests", repoPath: "/src/manifests/apps/" + RepoFile},
+		{trusted: "/src/manifests", repoPath: "/src/manifests-fork/" + RepoFile},
+		{trusted: "~/src/manifests", repoPath: filepath.Join(home, "src/manifests", RepoFile), want: true},
+		{trusted: "~/src/manifests", repoPath: "/src/manifests/" + RepoFile},
+		{trusted: "../../src", repoPath: "/home/me/src/" + RepoFile, want: true},
+		{trusted: "src", repoPath: "/src/" + RepoFile},
+	}
+	for _, tt := range tests {
+		c.TrustedRepos = []string{tt.trusted}
+		if got := c.trusts(tt.repoPath); got != tt.want {
+			t.Errorf("%s trusting %s: expected %v, got %v", tt.repoPath, tt.trusted, tt.want, got)
+		}
+	}
+}
+
+func TestMerge(t *testing.T) {
+	user := &Profile{Name: "debug", File: "user"}
+	repo := &Profile{Name: "debug", File: "repo"}
+	team := &Profile{Name: "team", File: "repo"}
+	c := &Config{
+		Transforms: []string{"user.star"},
+		Functions:  []mutate.Function{{Exec: "user-fn"}},
+		Profiles:   map[string]*Profile{"debug": user},
+		Files:      []string{"user"},
+	}
+	c.merge(&Config{
+		Transforms:  []string{"repo.star"},
+		Functions:   []mutate.Function{{Exec: "repo-fn"}},
+		Profiles:    map[string]*Profile{"debug": repo, "team": team},
+		DiffEditors: map[string]string{"code": "--diff"},
+		Files:       []string{"repo"},
+	})
+	want := &Config{
+		Transforms:  []string{"user.star", "repo.star"},
+		Functions:   []mutate.Function{{Exec: "user-fn"}, {Exec: "repo-fn"}},
+		Profiles:    map[string]*Profile{"debug": repo, "team": team},
+		DiffEditors: map[string]string{"code": "--diff"},
+		Files:       []string{"user", "repo"},
+	}
+	if !reflect.DeepEqual(c, want) {
+		t.Errorf("expected %+v, got %+v", want, c)
+	}
+}
@@ -0,0 +1,89 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"dup/pkg/mutate"
+)
+
+// Profile bundles duplication and mutation options under a name. Unset
+// fields keep the defaults of the flags.
+type Profile struct {
+	Description   string            `json:"description,omitempty"`
+	Pod           *bool             `json:"pod,omitempty"`
+	DisableProbes *bool             `json:"disableProbes,omitempty"`
+	CommandLoop   *bool             `json:"commandLoop,omitempty"`
+	TTL           string            `json:"ttl,omitempty"`
+	SkipEdit      *bool             `json:"skipEdit,omitempty"`
+	Set           []string          `json:"set,omitempty"`
+	Patches       []string          `json:"patches,omitempty"`
+	PatchFiles    []string          `json:"patchFiles,omitempty"`
+	PatchType     string            `json:"patchType,omitempty"`
+	Transforms    []string          `json:"transforms,omitempty"`
+	Functions     []mutate.Function `json:"functions,omitempty"`
+
+	// Name and File identify the profile, File being the config file defining it
+	Name string `json:"-"`
+	File string `json:"-"`
+	// ParsedTTL is TTL as a duration
+	ParsedTTL time.Duration `json:"-"`
+}
+
+// complete validates a profile read from the config file c and resolves its paths
+func (p *Profile) complete(c *Config, name string) error {
+	p.Name, p.File = name, c.path
+	if len(p.TTL) > 0 {
+		ttl, err := time.ParseDuration(p.TTL)
+		if err != nil {
+			return fmt.Errorf("profile %q: invalid ttl %q: %v", name, p.TTL, err)
+		}
+		p.ParsedTTL = ttl
+	}
+	p.PatchFiles = c.resolveAll(p.PatchFiles)
+	p.Transforms = c.resolveAll(p.Transforms)
+	p.Functions = c.resolveFunctions(p.Functions)
+	return nil
+}
+
+// Flags returns the command line flags equivalent to the profile
+func (p *Profile) Flags() []string {
+	var flags []string
+	addBool := func(name string, value *bool) {
+		if value != nil {
+			flags = append(flags, fmt.Sprintf("--%s=%s", name, strconv.FormatBool(*value)))
+		}
+	}
+	addBool("pod", p.Pod)
+	addBool("disable-probes", p.DisableProbes)
+	addBool("command-loop", p.CommandLoop)
+	addBool("skip-edit", p.SkipEdit)
+	if len(p.TTL) > 0 {
+		flags = append(flags, "--ttl", p.TTL)
+	}
+	for _, set := range p.Set {
+		flags = append(flags, "--set", strconv.Quote(set))
+	}
+	for _, patch := range p.Patches {
+		flags = append(flags, "--patch", strconv.Quote(patch))
+	}
+	for _, file := range p.PatchFiles {
+		flags = append(flags, "--patch-file", file)
+	}
+	if len(p.PatchType) > 0 {
+		flags = append(flags, "--patch-type", p.PatchType)
+	}
+	for _, transform := range p.Transforms {
+		flags = append(flags, "--transform", transform)
+	}
+	for _, fn := range p.Functions {
+		command := strings.Join(append([]string{fn.Exec}, fn.Args...), " ")
+		if fn.Config != nil {
+			command += " (with config)"
+		}
+		flags = append(flags, "--fn", strconv.Quote(command))
+	}
+	return flags
+}
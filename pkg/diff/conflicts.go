@@ -0,0 +1,70 @@
+package diff
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Conflicts returns the JSON pointers of the changes from base to theirs
+// that overlap a change from base to mine, where theirs and mine do not end
+// up with the same value.
+func Conflicts(base, theirs, mine interface{}) []string {
+	mineOps := JSONPatch(base, mine)
+	var ret []string
+	for _, theirsOp := range JSONPatch(base, theirs) {
+		for _, mineOp := range mineOps {
+			path, ok := commonPath(theirsOp.Path, mineOp.Path)
+			if !ok {
+				continue
+			}
+			// appends are compared as changes of the whole list
+			path = strings.TrimSuffix(path, "/-")
+			theirsValue, _ := lookup(theirs, path)
+			mineValue, _ := lookup(mine, path)
+			if !reflect.DeepEqual(theirsValue, mineValue) {
+				ret = append(ret, theirsOp.Path)
+				break
+			}
+		}
+	}
+	return ret
+}
+
+// commonPath returns the shorter of a and b if one contains the other
+func commonPath(a, b string) (string, bool) {
+	if len(b) < len(a) {
+		a, b = b, a
+	}
+	if a == b || strings.HasPrefix(b, a+"/") {
+		return a, true
+	}
+	return "", false
+}
+
+// lookup returns the value at the JSON pointer path of value
+func lookup(value interface{}, path string) (interface{}, bool) {
+	if len(path) == 0 {
+		return value, true
+	}
+	for _, token := range strings.Split(path[1:], "/") {
+		token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+		switch v := value.(type) {
+		case map[string]interface{}:
+			child, ok := v[token]
+			if !ok {
+				return nil, false
+			}
+			value = child
+		case []interface{}:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, false
+			}
+			value = v[i]
+		default:
+			return nil, false
+		}
+	}
+	return value, true
+}
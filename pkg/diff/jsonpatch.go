@@ -0,0 +1,98 @@
+package diff
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Operation is a single RFC 6902 JSON patch operation
+type Operation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// MarshalJSON keeps the value of add and replace operations, even when it is
+// null, and leaves it out of remove operations
+func (op Operation) MarshalJSON() ([]byte, error) {
+	if op.Op == "remove" {
+		return json.Marshal(struct {
+			Op   string `json:"op"`
+			Path string `json:"path"`
+		}{op.Op, op.Path})
+	}
+	return json.Marshal(struct {
+		Op    string      `json:"op"`
+		Path  string      `json:"path"`
+		Value interface{} `json:"value"`
+	}{op.Op, op.Path, op.Value})
+}
+
+// JSONPatch returns the RFC 6902 operations turning from into to. Both
+// are decoded JSON values. Lists are compared index by index, with
+// trailing items added or removed.
+func JSONPatch(from, to interface{}) []Operation {
+	return appendPatch(nil, "", from, to)
+}
+
+func appendPatch(ops []Operation, path string, from, to interface{}) []Operation {
+	if reflect.DeepEqual(from, to) {
+		return ops
+	}
+	switch fromValue := from.(type) {
+	case map[string]interface{}:
+		toValue, ok := to.(map[string]interface{})
+		if !ok {
+			break
+		}
+		for _, key := range sortedKeys(fromValue) {
+			if _, found := toValue[key]; !found {
+				ops = append(ops, Operation{Op: "remove", Path: path + "/" + escapePointer(key)})
+			}
+		}
+		for _, key := range sortedKeys(toValue) {
+			child := path + "/" + escapePointer(key)
+			if fromChild, found := fromValue[key]; found {
+				ops = appendPatch(ops, child, fromChild, toValue[key])
+			} else {
+				ops = append(ops, Operation{Op: "add", Path: child, Value: toValue[key]})
+			}
+		}
+		return ops
+	case []interface{}:
+		toValue, ok := to.([]interface{})
+		if !ok {
+			break
+		}
+		common := min(len(fromValue), len(toValue))
+		for i := 0; i < common; i++ {
+			ops = appendPatch(ops, path+"/"+strconv.Itoa(i), fromValue[i], toValue[i])
+		}
+		// remove from the end so earlier indexes stay valid
+		for i := len(fromValue) - 1; i >= common; i-- {
+			ops = append(ops, Operation{Op: "remove", Path: path + "/" + strconv.Itoa(i)})
+		}
+		for i := common; i < len(toValue); i++ {
+			ops = append(ops, Operation{Op: "add", Path: path + "/-", Value: toValue[i]})
+		}
+		return ops
+	}
+	return append(ops, Operation{Op: "replace", Path: path, Value: to})
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// escapePointer escapes a key for use in a JSON pointer (RFC 6901)
+func escapePointer(key string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+}
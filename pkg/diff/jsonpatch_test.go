@@ -0,0 +1,157 @@
+package diff
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	jsonpatch "github.com/evanphx/json-patch"
+)
+
+func TestJSONPatch(t *testing.T) {
+	tests := []struct {
+		name string
+		from string
+		to   string
+		want []Operation
+	}{
+		{
+			name: "empty objects",
+			from: `{}`,
+			to:   `{}`,
+		},
+		{
+			name: "from an empty object",
+			from: `{}`,
+			to:   `{"a": 1}`,
+			want: []Operation{{Op: "add", Path: "/a", Value: float64(1)}},
+		},
+		{
+			name: "to an empty object",
+			from: `{"a": 1, "b": {"c": true}}`,
+			to:   `{}`,
+			want: []Operation{{Op: "remove", Path: "/a"}, {Op: "remove", Path: "/b"}},
+		},
+		{
+			name: "nested replace",
+			from: `{"spec": {"replicas": 1, "paused": false}}`,
+			to:   `{"spec": {"replicas": 2, "paused": false}}`,
+			want: []Operation{{Op: "replace", Path: "/spec/replicas", Value: float64(2)}},
+		},
+		{
+			name: "escaped keys",
+			from: `{"metadata": {"labels": {"app.kubernetes.io/name": "web", "a~b": "1"}}}`,
+			to:   `{"metadata": {"labels": {"app.kubernetes.io/name": "api", "a~b": "2"}}}`,
+			want: []Operation{
+				{Op: "replace", Path: "/metadata/labels/app.kubernetes.io~1name", Value: "api"},
+				{Op: "replace", Path: "/metadata/labels/a~0b", Value: "2"},
+			},
+		},
+		{
+			name: "reordered list",
+			from: `{"args": ["a", "b", "c"]}`,
+			to:   `{"args": ["c", "a", "b"]}`,
+			want: []Operation{
+				{Op: "replace", Path: "/args/0", Value: "c"},
+				{Op: "replace", Path: "/args/1", Value: "a"},
+				{Op: "replace", Path: "/args/2", Value: "b"},
+			},
+		},
+		{
+			name: "shorter list",
+			from: `{"args": ["a", "b", "c", "d"]}`,
+			to:   `{"args": ["a", "x"]}`,
+			want: []Operation{
+				{Op: "replace", Path: "/args/1", Value: "x"},
+				{Op: "remove", Path: "/args/3"},
+				{Op: "remove", Path: "/args/2"},
+			},
+		},
+		{
+			name: "longer list",
+			from: `{"args": []}`,
+			to:   `{"args": ["a", {"b": 1}]}`,
+			want: []Operation{
+				{Op: "add", Path: "/args/-", Value: "a"},
+				{Op: "add", Path: "/args/-", Value: map[string]interface{}{"b": float64(1)}},
+			},
+		},
+		{
+			name: "type change",
+			from: `{"value": {"a": 1}}`,
+			to:   `{"value": [1]}`,
+			want: []Operation{{Op: "replace", Path: "/value", Value: []interface{}{float64(1)}}},
+		},
+		{
+			name: "null values",
+			from: `{"a": 1, "b": null}`,
+			to:   `{"a": null, "b": null, "c": null}`,
+			want: []Operation{
+				{Op: "replace", Path: "/a", Value: nil},
+				{Op: "add", Path: "/c", Value: nil},
+			},
+		},
+		{
+			name: "whole document",
+			from: `{"a": 1}`,
+			to:   `[1]`,
+			want: []Operation{{Op: "replace", Path: "", Value: []interface{}{float64(1)}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var from, to interface{}
+			if err := json.Unmarshal([]byte(tt.from), &from); err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal([]byte(tt.to), &to); err != nil {
+				t.Fatal(err)
+			}
+			got := JSONPatch(from, to)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("expected %+v, got %+v", tt.want, got)
+			}
+			if len(got) == 0 || tt.want[0].Path == "" {
+				return
+			}
+
+			// the patch turns from into to
+			data, err := json.Marshal(got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			patch, err := jsonpatch.DecodePatch(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			patched, err := patch.Apply([]byte(tt.from))
+			if err != nil {
+				t.Fatalf("applying %s: %v", data, err)
+			}
+			if !jsonpatch.Equal(patched, []byte(tt.to)) {
+				t.Errorf("applying %s: expected %s, got %s", data, tt.to, patched)
+			}
+		})
+	}
+}
+
+func TestOperationJSON(t *testing.T) {
+	tests := []struct {
+		op   Operation
+		want string
+	}{
+		{op: Operation{Op: "remove", Path: "/a"}, want: `{"op":"remove","path":"/a"}`},
+		{op: Operation{Op: "replace", Path: "/a", Value: nil}, want: `{"op":"replace","path":"/a","value":null}`},
+		{op: Operation{Op: "add", Path: "/a", Value: ""}, want: `{"op":"add","path":"/a","value":""}`},
+		{op: Operation{Op: "add", Path: "/a", Value: false}, want: `{"op":"add","path":"/a","value":false}`},
+	}
+	for _, tt := range tests {
+		data, err := json.Marshal(tt.op)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != tt.want {
+			t.Errorf("expected %s, got %s", tt.want, data)
+		}
+	}
+}
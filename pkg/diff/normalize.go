@@ -0,0 +1,130 @@
+package diff
+
+import (
+	"strings"
+
+	"dup/pkg/duplicate"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// serviceAccountVolumePrefix prefixes the projected token volume the API
+// server injects into pods with a random suffix.
+const serviceAccountVolumePrefix = "kube-api-access-"
+
+// metadataFields are server populated or identify a single object, they
+// never differ meaningfully between a source and its duplicate.
+var metadataFields = append([]string{
+	"name", "generateName", "namespace", "deletionTimestamp", "deletionGracePeriodSeconds",
+	"ownerReferences", "finalizers",
+}, duplicate.ServerMetadataFields...)
+
+// serverAnnotations and serverLabels are maintained by controllers
+var serverAnnotations = []string{
+	"kubectl.kubernetes.io/last-applied-configuration",
+	"deployment.kubernetes.io/revision",
+}
+
+var serverLabels = []string{
+	"pod-template-hash",
+	"controller-revision-hash",
+	"statefulset.kubernetes.io/pod-name",
+	"controller-uid",
+	"job-name",
+	"batch.kubernetes.io/controller-uid",
+	"batch.kubernetes.io/job-name",
+}
+
+// Normalize returns a copy of obj stripped of everything that always
+// differs between a source and its duplicate: identity, status, managed
+// fields, dup's own provenance metadata and server generated names.
+func Normalize(obj *unstructured.Unstructured) *unstructured.Unstructured {
+	ret := obj.DeepCopy()
+	for _, field := range metadataFields {
+		unstructured.RemoveNestedField(ret.Object, "metadata", field)
+	}
+	delete(ret.Object, "status")
+
+	// pods created from a template are named by the server
+	if ret.GetKind() == "Pod" {
+		unstructured.RemoveNestedField(ret.Object, "spec", "nodeName")
+	}
+	// the server generates a unique selector for every Job
+	if ret.GetKind() == "Job" {
+		unstructured.RemoveNestedField(ret.Object, "spec", "selector")
+	}
+
+	normalizeValue(ret.Object)
+	if metadata, ok := ret.Object["metadata"].(map[string]interface{}); ok && len(metadata) == 0 {
+		delete(ret.Object, "metadata")
+	}
+	return ret
+}
+
+// normalizeValue walks value, cleaning up every metadata map and pod spec it finds
+func normalizeValue(value interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			switch key {
+			case "labels":
+				v[key] = cleanMetadataMap(child, serverLabels)
+			case "annotations":
+				v[key] = cleanMetadataMap(child, serverAnnotations)
+			case "volumes", "volumeMounts":
+				renameServiceAccountVolumes(child)
+			case "creationTimestamp":
+				if child == nil {
+					delete(v, key)
+					continue
+				}
+			}
+			if v[key] == nil {
+				delete(v, key)
+				continue
+			}
+			normalizeValue(v[key])
+		}
+	case []interface{}:
+		for _, child := range v {
+			normalizeValue(child)
+		}
+	}
+}
+
+// cleanMetadataMap drops dup's keys and the server managed keys from a
+// labels or annotations map, returning nil when nothing is left.
+func cleanMetadataMap(value interface{}, serverKeys []string) interface{} {
+	m, ok := value.(map[string]interface{})
+	if !ok {
+		return value
+	}
+	for key, v := range m {
+		if value, _ := v.(string); duplicate.IsOwnedMetadata(key, value) {
+			delete(m, key)
+		}
+	}
+	for _, key := range serverKeys {
+		delete(m, key)
+	}
+	if len(m) == 0 {
+		return nil
+	}
+	return m
+}
+
+func renameServiceAccountVolumes(value interface{}) {
+	items, ok := value.([]interface{})
+	if !ok {
+		return
+	}
+	for _, item := range items {
+		volume, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if name, ok := volume["name"].(string); ok && strings.HasPrefix(name, serviceAccountVolumePrefix) {
+			volume["name"] = strings.TrimSuffix(serviceAccountVolumePrefix, "-")
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package diff
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	colorReset = "\033[0m"
+	colorRed   = "\033[31m"
+	colorGreen = "\033[32m"
+	colorCyan  = "\033[36m"
+	colorBold  = "\033[1m"
+)
+
+// contextLines is the number of unchanged lines printed around each change
+const contextLines = 3
+
+type opKind int
+
+const (
+	opEqual opKind = iota
+	opDelete
+	opInsert
+)
+
+type lineOp struct {
+	kind opKind
+	line string
+	// 1-based line numbers in the from and to texts
+	fromLine, toLine int
+}
+
+// Unified writes a unified diff turning from into to, labelled with
+// fromName and toName, coloring it for terminals when color is set. It
+// returns whether the texts differ.
+func Unified(w io.Writer, fromName, toName, from, to string, color bool) (bool, error) {
+	ops := diffLines(splitLines(from), splitLines(to))
+	changed := false
+	for _, op := range ops {
+		if op.kind != opEqual {
+			changed = true
+			break
+		}
+	}
+	if !changed {
+		return false, nil
+	}
+
+	paint := func(c, s string) string {
+		if !color {
+			return s
+		}
+		return c + s + colorReset
+	}
+	if _, err := fmt.Fprintf(w, "%s\n%s\n", paint(colorBold, "--- "+fromName), paint(colorBold, "+++ "+toName)); err != nil {
+		return true, err
+	}
+	for _, h := range hunks(ops) {
+		fromStart, fromCount, toStart, toCount := h.bounds()
+		fmt.Fprintln(w, paint(colorCyan, fmt.Sprintf("@@ -%d,%d +%d,%d @@", fromStart, fromCount, toStart, toCount)))
+		for _, op := range h {
+			switch op.kind {
+			case opEqual:
+				fmt.Fprintf(w, " %s\n", op.line)
+			case opDelete:
+				fmt.Fprintln(w, paint(colorRed, "-"+op.line))
+			case opInsert:
+				fmt.Fprintln(w, paint(colorGreen, "+"+op.line))
+			}
+		}
+	}
+	return true, nil
+}
+
+func splitLines(s string) []string {
+	if len(s) == 0 {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// diffLines computes the line operations turning a into b from their longest
+// common subsequence. Common prefixes and suffixes are trimmed first, which
+// keeps the quadratic part small for the mostly identical manifests dup compares.
+func diffLines(a, b []string) []lineOp {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	midA, midB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+
+	// lcs[i][j] is the length of the LCS of midA[i:] and midB[j:]
+	lcs := make([][]int32, len(midA)+1)
+	for i := range lcs {
+		lcs[i] = make([]int32, len(midB)+1)
+	}
+	for i := len(midA) - 1; i >= 0; i-- {
+		for j := len(midB) - 1; j >= 0; j-- {
+			if midA[i] == midB[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var ops []lineOp
+	ai, bi := 0, 0
+	equal := func(line string) {
+		ai++
+		bi++
+		ops = append(ops, lineOp{kind: opEqual, line: line, fromLine: ai, toLine: bi})
+	}
+	for i := 0; i < prefix; i++ {
+		equal(a[i])
+	}
+	i, j := 0, 0
+	for i < len(midA) || j < len(midB) {
+		switch {
+		case i < len(midA) && j < len(midB) && midA[i] == midB[j]:
+			equal(midA[i])
+			i++
+			j++
+		case j < len(midB) && (i == len(midA) || lcs[i][j+1] > lcs[i+1][j]):
+			bi++
+			ops = append(ops, lineOp{kind: opInsert, line: midB[j], fromLine: ai, toLine: bi})
+			j++
+		default:
+			ai++
+			ops = append(ops, lineOp{kind: opDelete, line: midA[i], fromLine: ai, toLine: bi})
+			i++
+		}
+	}
+	for i := len(a) - suffix; i < len(a); i++ {
+		equal(a[i])
+	}
+	return ops
+}
+
+type hunk []lineOp
+
+// hunks groups changed lines with contextLines of context, merging
+// groups whose context overlaps.
+func hunks(ops []lineOp) []hunk {
+	var ret []hunk
+	start, end := -1, -1
+	for i, op := range ops {
+		if op.kind == opEqual {
+			continue
+		}
+		lo, hi := max(0, i-contextLines), min(len(ops), i+contextLines+1)
+		if start >= 0 && lo <= end {
+			end = hi
+			continue
+		}
+		if start >= 0 {
+			ret = append(ret, hunk(ops[start:end]))
+		}
+		start, end = lo, hi
+	}
+	if start >= 0 {
+		ret = append(ret, hunk(ops[start:end]))
+	}
+	return ret
+}
+
+// bounds returns the start and length of h in both texts, as printed in the hunk header
+func (h hunk) bounds() (fromStart, fromCount, toStart, toCount int) {
+	for _, op := range h {
+		switch op.kind {
+		case opEqual:
+			fromCount++
+			toCount++
+		case opDelete:
+			fromCount++
+		case opInsert:
+			toCount++
+		}
+	}
+	first := h[0]
+	fromStart, toStart = first.fromLine, first.toLine
+	switch first.kind {
+	case opInsert:
+		fromStart++
+	case opDelete:
+		toStart++
+	}
+	if fromCount == 0 {
+		fromStart--
+	}
+	if toCount == 0 {
+		toStart--
+	}
+	return fromStart, fromCount, toStart, toCount
+}
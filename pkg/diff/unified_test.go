@@ -0,0 +1,131 @@
+package diff
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// numbers returns the lines 1 to n, with the lines of replace changed
+func numbers(n int, replace map[int]string) string {
+	var b strings.Builder
+	for i := 1; i <= n; i++ {
+		if line, ok := replace[i]; ok {
+			b.WriteString(line + "\n")
+		} else {
+			fmt.Fprintf(&b, "%d\n", i)
+		}
+	}
+	return b.String()
+}
+
+func TestUnified(t *testing.T) {
+	tests := []struct {
+		name string
+		from string
+		to   string
+		// want is the output after the file names, empty when the texts are equal
+		want string
+	}{
+		{name: "both empty"},
+		{
+			name: "equal",
+			from: "a\nb\n",
+			to:   "a\nb\n",
+		},
+		{
+			name: "equal but for the final newline",
+			from: "a\nb",
+			to:   "a\nb\n",
+		},
+		{
+			name: "from empty",
+			to:   "a\nb\n",
+			want: "@@ -0,0 +1,2 @@\n+a\n+b\n",
+		},
+		{
+			name: "to empty",
+			from: "a\nb\n",
+			want: "@@ -1,2 +0,0 @@\n-a\n-b\n",
+		},
+		{
+			name: "context around a change",
+			from: numbers(10, nil),
+			to:   numbers(10, map[int]string{5: "five"}),
+			want: "@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n",
+		},
+		{
+			name: "change on the first line",
+			from: numbers(5, nil),
+			to:   numbers(5, map[int]string{1: "one"}),
+			want: "@@ -1,4 +1,4 @@\n-1\n+one\n 2\n 3\n 4\n",
+		},
+		{
+			name: "insertion at the end",
+			from: numbers(5, nil),
+			to:   numbers(6, nil),
+			want: "@@ -3,3 +3,4 @@\n 3\n 4\n 5\n+6\n",
+		},
+		{
+			name: "hunks with overlapping context are merged",
+			from: numbers(20, nil),
+			to:   numbers(20, map[int]string{3: "x", 10: "y"}),
+			want: "@@ -1,13 +1,13 @@\n 1\n 2\n-3\n+x\n 4\n 5\n 6\n 7\n 8\n 9\n-10\n+y\n 11\n 12\n 13\n",
+		},
+		{
+			name: "distant changes get their own hunks",
+			from: numbers(20, nil),
+			to:   numbers(20, map[int]string{3: "x", 11: "y"}),
+			want: "@@ -1,6 +1,6 @@\n 1\n 2\n-3\n+x\n 4\n 5\n 6\n" +
+				"@@ -8,7 +8,7 @@\n 8\n 9\n 10\n-11\n+y\n 12\n 13\n 14\n",
+		},
+		{
+			name: "reordered lines",
+			from: "a\nb\nc\n",
+			to:   "c\na\nb\n",
+			want: "@@ -1,3 +1,3 @@\n+c\n a\n b\n-c\n",
+		},
+		{
+			name: "removed list item",
+			from: "containers:\n- name: app\n- name: proxy\n- name: debug\n",
+			to:   "containers:\n- name: app\n- name: debug\n",
+			want: "@@ -1,4 +1,3 @@\n containers:\n - name: app\n-- name: proxy\n - name: debug\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			changed, err := Unified(out, "from", "to", tt.from, tt.to, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if changed != (len(tt.want) > 0) {
+				t.Errorf("expected changed to be %v", len(tt.want) > 0)
+			}
+			want := ""
+			if len(tt.want) > 0 {
+				want = "--- from\n+++ to\n" + tt.want
+			}
+			if out.String() != want {
+				t.Errorf("expected\n%s\ngot\n%s", want, out)
+			}
+		})
+	}
+}
+
+func TestUnifiedColor(t *testing.T) {
+	out := &bytes.Buffer{}
+	if _, err := Unified(out, "from", "to", "a\nb\n", "a\nc\n", true); err != nil {
+		t.Fatal(err)
+	}
+	want := colorBold + "--- from" + colorReset + "\n" +
+		colorBold + "+++ to" + colorReset + "\n" +
+		colorCyan + "@@ -1,2 +1,2 @@" + colorReset + "\n" +
+		" a\n" +
+		colorRed + "-b" + colorReset + "\n" +
+		colorGreen + "+c" + colorReset + "\n"
+	if out.String() != want {
+		t.Errorf("expected %q, got %q", want, out)
+	}
+}
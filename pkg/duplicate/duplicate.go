@@ -4,6 +4,7 @@ import (
 	duputil "dup/pkg/util"
 	"fmt"
 	"strings"
+	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
 	batchv1 "k8s.io/api/batch/v1"
@@ -22,26 +23,44 @@ type PodOptions struct {
 	DisableProbes     bool
 	LoopCommand       bool
 	Image             string
+	TTL               time.Duration
+	// Name replaces the generated name of the duplicate, so that repeated
+	// runs target the same object
+	Name string
 }
 
 func Clone(opts *PodOptions, objects []*resource.Info) ([]*runtime.Object, error) {
 	var ret []*runtime.Object
+	now := time.Now()
+	if opts != nil && len(opts.Name) > 0 && len(objects) > 1 {
+		return nil, fmt.Errorf("--name can only be given when duplicating a single object, got %d", len(objects))
+	}
 	for i := range objects {
+		var (
+			dResource *runtime.Object
+			mutations []Mutation
+			err       error
+		)
 		obj := objects[i]
 		objKind := obj.Object.GetObjectKind().GroupVersionKind().Kind
 		if hasPodSpec(objKind) {
-			dResource, err := cloneResourceWithPod(obj.Object, opts)
-			if err != nil {
-				return nil, err
-			}
-			ret = append(ret, dResource)
+			dResource, mutations, err = cloneResourceWithPod(obj.Object, opts)
 		} else {
-			dResource, err := cloneGenericResource(obj.Object)
-			if err != nil {
+			dResource, err = cloneGenericResource(obj.Object, opts)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if opts != nil && opts.TTL > 0 {
+			if err := applyTTL(*dResource, opts.TTL, now); err != nil {
 				return nil, err
 			}
-			ret = append(ret, dResource)
+			mutations = append(mutations, Mutation{Type: MutationTTL, Value: opts.TTL.String()})
 		}
+		if err := setProvenance(*dResource, obj.Object, mutations); err != nil {
+			return nil, err
+		}
+		ret = append(ret, dResource)
 	}
 	return ret, nil
 }
@@ -66,7 +85,7 @@ func unstructuredToType(in runtime.Object, out runtime.Object) error {
 	return nil
 }
 
-func cloneResourceWithPod(obj runtime.Object, opts *PodOptions) (*runtime.Object, error) {
+func cloneResourceWithPod(obj runtime.Object, opts *PodOptions) (*runtime.Object, []Mutation, error) {
 	var dupObject runtime.Object
 	var metadata *metav1.ObjectMeta
 	var spec *corev1.PodSpec
@@ -93,19 +112,23 @@ func cloneResourceWithPod(obj runtime.Object, opts *PodOptions) (*runtime.Object
 		unstructuredToType(obj, dupObject)
 		metadata, spec = extractPod[PodAdapter](PodAdapter{dupObject.(*corev1.Pod)})
 	default:
-		return nil, fmt.Errorf("object type %s does not have PodSpec or is not supported", objType)
+		return nil, nil, fmt.Errorf("object type %s does not have PodSpec or is not supported", objType)
 	}
 
-	applyOptions(objType, spec, metadata, opts)
-	err := setSuffixedName(&dupObject)
+	mutations := applyOptions(objType, spec, metadata, opts)
+	err := setName(&dupObject, opts)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	return &dupObject, nil
+	return &dupObject, mutations, nil
 }
 
-func setSuffixedName(obj *runtime.Object) error {
+// setName names obj after opts.Name, or its current name with a random suffix
+func setName(obj *runtime.Object, opts *PodOptions) error {
 	accessor := meta.NewAccessor()
+	if opts != nil && len(opts.Name) > 0 {
+		return accessor.SetName(*obj, opts.Name)
+	}
 	name, err := accessor.Name(*obj)
 	if err != nil {
 		return err
@@ -115,35 +138,48 @@ func setSuffixedName(obj *runtime.Object) error {
 	return nil
 }
 
-func cloneGenericResource(obj runtime.Object) (*runtime.Object, error) {
+func cloneGenericResource(obj runtime.Object, opts *PodOptions) (*runtime.Object, error) {
 	objCopy := obj.DeepCopyObject()
-	err := setSuffixedName(&objCopy)
+	err := setName(&objCopy, opts)
 	if err != nil {
 		return nil, err
 	}
 
 	return &objCopy, nil
 }
-func applyOptions(kind string, spec *corev1.PodSpec, meta *metav1.ObjectMeta, opts *PodOptions) {
+
+// applyOptions mutates the pod template according to opts and returns the
+// mutations that were made.
+func applyOptions(kind string, spec *corev1.PodSpec, meta *metav1.ObjectMeta, opts *PodOptions) []Mutation {
+	var mutations []Mutation
 	if opts != nil {
-		if opts.DisableProbes {
-			disableProbes(spec)
+		if opts.DisableProbes && disableProbes(spec) {
+			mutations = append(mutations, Mutation{Type: MutationProbes})
 		}
 		if opts.LoopCommand {
 			setCommand(spec)
+			mutations = append(mutations, Mutation{Type: MutationLoopCommand, Value: LOOP_COMMAND})
 		}
 		if kind == "Pod" {
 			removeOwnership(meta)
+			mutations = append(mutations, Mutation{Type: MutationOwnership})
 		}
 	}
+	return mutations
 }
 
-func disableProbes(podSpec *corev1.PodSpec) {
+// disableProbes removes readiness and liveness probes, returns whether any were set
+func disableProbes(podSpec *corev1.PodSpec) bool {
+	removed := false
 	containers := podSpec.Containers
 	for i := range containers {
+		if containers[i].ReadinessProbe != nil || containers[i].LivenessProbe != nil {
+			removed = true
+		}
 		containers[i].ReadinessProbe = nil
 		containers[i].LivenessProbe = nil
 	}
+	return removed
 }
 
 func setCommand(podSpec *corev1.PodSpec) {
@@ -0,0 +1,50 @@
+package duplicate
+
+import "fmt"
+
+// types of the mutations recorded in MutationsAnnotation, they are stable
+// identifiers that other commands rely on to undo or reapply mutations
+const (
+	MutationProbes      = "disable-probes"
+	MutationLoopCommand = "command-loop"
+	MutationOwnership   = "remove-ownership"
+	MutationTTL         = "ttl"
+
+	// changes requested with the mutate options, Value holds the patch, the
+	// path of the patch file or script, the setter or the function command
+	MutationPatch     = "patch"
+	MutationPatchFile = "patch-file"
+	MutationSet       = "set"
+	MutationTransform = "transform"
+	MutationFunction  = "function"
+
+	// a change made in the --interactive menus, Value describes it
+	MutationInteractive = "interactive"
+)
+
+// Mutation is a change dup applied to a duplicate, Value holds its parameter
+// such as the loop command or the TTL.
+type Mutation struct {
+	Type  string `json:"type"`
+	Value string `json:"value,omitempty"`
+}
+
+// String describes the mutation for display
+func (m Mutation) String() string {
+	switch m.Type {
+	case MutationProbes:
+		return "removed readiness and liveness probes"
+	case MutationLoopCommand:
+		return fmt.Sprintf("replaced container commands with %q", m.Value)
+	case MutationOwnership:
+		return "removed owner references and app.kubernetes.io instance/name labels"
+	case MutationTTL:
+		return "expires after " + m.Value
+	case MutationPatchFile:
+		return "patch from " + m.Value
+	}
+	if len(m.Value) == 0 {
+		return m.Type
+	}
+	return m.Type + " " + m.Value
+}
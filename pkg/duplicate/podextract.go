@@ -5,6 +5,8 @@ import (
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
 type MetadataSpecExtractor[T any] interface {
@@ -75,3 +77,43 @@ func (s PodAdapter) GetPodMetadata() *metav1.ObjectMeta {
 func (s PodAdapter) GetPodSpec() *corev1.PodSpec {
 	return &s.Pod.Spec
 }
+
+// PodSpecOf returns obj as its Go type along with its pod spec, unstructured
+// objects are converted first. ok is false for kinds without a pod spec.
+func PodSpecOf(obj runtime.Object) (typed runtime.Object, spec *corev1.PodSpec, ok bool, err error) {
+	if u, isUnstructured := obj.(*unstructured.Unstructured); isUnstructured {
+		switch u.GetKind() {
+		case "StatefulSet":
+			typed = &appsv1.StatefulSet{}
+		case "Deployment":
+			typed = &appsv1.Deployment{}
+		case "CronJob":
+			typed = &batchv1.CronJob{}
+		case "Job":
+			typed = &batchv1.Job{}
+		case "Pod":
+			typed = &corev1.Pod{}
+		default:
+			return obj, nil, false, nil
+		}
+		if err := unstructuredToType(u, typed); err != nil {
+			return nil, nil, false, err
+		}
+		obj = typed
+	}
+	switch o := obj.(type) {
+	case *appsv1.StatefulSet:
+		_, spec = extractPod[StatefulSetAdapter](StatefulSetAdapter{o})
+	case *appsv1.Deployment:
+		_, spec = extractPod[DeploymentAdapter](DeploymentAdapter{o})
+	case *batchv1.CronJob:
+		_, spec = extractPod[CronJobAdapter](CronJobAdapter{o})
+	case *batchv1.Job:
+		_, spec = extractPod[JobAdapter](JobAdapter{o})
+	case *corev1.Pod:
+		_, spec = extractPod[PodAdapter](PodAdapter{o})
+	default:
+		return obj, nil, false, nil
+	}
+	return obj, spec, true, nil
+}
@@ -0,0 +1,260 @@
+package duplicate
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// MetadataPrefix prefixes every label and annotation owned by dup
+	MetadataPrefix = "dup.vash.io/"
+
+	// ManagedByLabel is set to ManagedByValue on every duplicate
+	ManagedByLabel = "app.kubernetes.io/managed-by"
+	ManagedByValue = "kubectl-dup"
+
+	// SourceUIDLabel holds the uid of the object a duplicate was cloned from
+	SourceUIDLabel = MetadataPrefix + "source-uid"
+	// GroupLabel is shared by every object created by a single dup invocation
+	GroupLabel = MetadataPrefix + "group"
+
+	// SourceAnnotation holds a JSON encoded Source
+	SourceAnnotation = MetadataPrefix + "source"
+	// CreatedByAnnotation holds the user that created the duplicate
+	CreatedByAnnotation = MetadataPrefix + "created-by"
+	// CreatedAtAnnotation holds the RFC3339 creation time of the duplicate
+	CreatedAtAnnotation = MetadataPrefix + "created-at"
+	// MutationsAnnotation holds a JSON list of the Mutations dup applied
+	MutationsAnnotation = MetadataPrefix + "mutations"
+)
+
+// ServerMetadataFields are the metadata fields populated by the server, they
+// are never sent back when creating or applying a duplicate.
+var ServerMetadataFields = []string{"uid", "resourceVersion", "creationTimestamp", "generation", "managedFields", "selfLink"}
+
+// RemoveServerMetadata removes the ServerMetadataFields of u
+func RemoveServerMetadata(u *unstructured.Unstructured) {
+	for _, field := range ServerMetadataFields {
+		unstructured.RemoveNestedField(u.Object, "metadata", field)
+	}
+}
+
+// Source references the object a duplicate was cloned from
+type Source struct {
+	APIVersion      string    `json:"apiVersion"`
+	Kind            string    `json:"kind"`
+	Namespace       string    `json:"namespace,omitempty"`
+	Name            string    `json:"name"`
+	UID             types.UID `json:"uid"`
+	ResourceVersion string    `json:"resourceVersion"`
+}
+
+func (s Source) String() string {
+	if len(s.Namespace) == 0 {
+		return fmt.Sprintf("%s/%s", s.Kind, s.Name)
+	}
+	return fmt.Sprintf("%s/%s/%s", s.Kind, s.Namespace, s.Name)
+}
+
+// SourceOf returns the source recorded on a duplicate, ok is false when
+// obj carries no provenance.
+func SourceOf(obj metav1.Object) (source Source, ok bool, err error) {
+	value, ok := obj.GetAnnotations()[SourceAnnotation]
+	if !ok {
+		return Source{}, false, nil
+	}
+	if err := json.Unmarshal([]byte(value), &source); err != nil {
+		return Source{}, false, fmt.Errorf("invalid %s annotation on %q: %v", SourceAnnotation, obj.GetName(), err)
+	}
+	return source, true, nil
+}
+
+// MutationsOf returns the mutations recorded on a duplicate
+func MutationsOf(obj metav1.Object) ([]Mutation, error) {
+	value, ok := obj.GetAnnotations()[MutationsAnnotation]
+	if !ok {
+		return nil, nil
+	}
+	var mutations []Mutation
+	if err := json.Unmarshal([]byte(value), &mutations); err != nil {
+		return nil, fmt.Errorf("invalid %s annotation on %q: %v", MutationsAnnotation, obj.GetName(), err)
+	}
+	return mutations, nil
+}
+
+// AddMutations records mutations after the ones already applied to dup
+func AddMutations(dup runtime.Object, mutations ...Mutation) error {
+	accessor, err := meta.Accessor(dup)
+	if err != nil {
+		return err
+	}
+	recorded, err := MutationsOf(accessor)
+	if err != nil {
+		return err
+	}
+	encoded, err := json.Marshal(append(recorded, mutations...))
+	if err != nil {
+		return err
+	}
+	annotations := accessor.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[MutationsAnnotation] = string(encoded)
+	accessor.SetAnnotations(annotations)
+	return nil
+}
+
+// setProvenance records the source of dup and the mutations applied to it
+func setProvenance(dup runtime.Object, source runtime.Object, mutations []Mutation) error {
+	sourceMeta, err := meta.Accessor(source)
+	if err != nil {
+		return err
+	}
+	apiVersion, kind := source.GetObjectKind().GroupVersionKind().ToAPIVersionAndKind()
+	encodedSource, err := json.Marshal(Source{
+		APIVersion:      apiVersion,
+		Kind:            kind,
+		Namespace:       sourceMeta.GetNamespace(),
+		Name:            sourceMeta.GetName(),
+		UID:             sourceMeta.GetUID(),
+		ResourceVersion: sourceMeta.GetResourceVersion(),
+	})
+	if err != nil {
+		return err
+	}
+	if mutations == nil {
+		mutations = []Mutation{}
+	}
+	encodedMutations, err := json.Marshal(mutations)
+	if err != nil {
+		return err
+	}
+
+	dupMeta, err := meta.Accessor(dup)
+	if err != nil {
+		return err
+	}
+	labels := dupMeta.GetLabels()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	labels[ManagedByLabel] = ManagedByValue
+	if uid := sourceMeta.GetUID(); len(uid) > 0 {
+		labels[SourceUIDLabel] = string(uid)
+	}
+	dupMeta.SetLabels(labels)
+
+	annotations := dupMeta.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[SourceAnnotation] = string(encodedSource)
+	annotations[MutationsAnnotation] = string(encodedMutations)
+	dupMeta.SetAnnotations(annotations)
+	return recordSourceTemplate(dupMeta, source)
+}
+
+// podTemplatePaths returns the paths of the metadata of objects templated by kind
+func podTemplatePaths(kind string) [][]string {
+	switch kind {
+	case "Deployment", "StatefulSet", "DaemonSet", "ReplicaSet", "ReplicationController", "Job":
+		return [][]string{{"spec", "template", "metadata"}}
+	case "CronJob":
+		return [][]string{
+			{"spec", "jobTemplate", "metadata"},
+			{"spec", "jobTemplate", "spec", "template", "metadata"},
+		}
+	}
+	return nil
+}
+
+// Stamp records who created obj, when, and in which invocation group, right
+// before it is sent to the server. It copies dup's labels and annotations into
+// the templates of obj so that its children inherit them.
+func Stamp(obj *unstructured.Unstructured, creator, group string, now time.Time) {
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	if len(creator) > 0 {
+		annotations[CreatedByAnnotation] = creator
+	}
+	annotations[CreatedAtAnnotation] = now.UTC().Format(time.RFC3339)
+	obj.SetAnnotations(annotations)
+
+	labels := obj.GetLabels()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	labels[ManagedByLabel] = ManagedByValue
+	if len(group) > 0 {
+		labels[GroupLabel] = group
+	}
+	obj.SetLabels(labels)
+
+	for _, path := range podTemplatePaths(obj.GetKind()) {
+		// skip templates missing from the edited object
+		if _, found, _ := unstructured.NestedMap(obj.Object, path[:len(path)-1]...); !found {
+			continue
+		}
+		mergeNestedStrings(obj.Object, dupMetadata(labels), append(path, "labels")...)
+		mergeNestedStrings(obj.Object, dupMetadata(annotations), append(path, "annotations")...)
+	}
+
+	// claims provisioned from a StatefulSet are grouped with it, so they can be found on deletion
+	if obj.GetKind() == "StatefulSet" {
+		templates, found, _ := unstructured.NestedSlice(obj.Object, "spec", "volumeClaimTemplates")
+		if !found {
+			return
+		}
+		for i := range templates {
+			template, ok := templates[i].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			mergeNestedStrings(template, dupMetadata(labels), "metadata", "labels")
+			templates[i] = template
+		}
+		unstructured.SetNestedSlice(obj.Object, templates, "spec", "volumeClaimTemplates")
+	}
+}
+
+// dupMetadata filters m down to the keys owned by dup, leaving out the
+// recorded source template which is only meaningful on the duplicate itself
+func dupMetadata(m map[string]string) map[string]string {
+	ret := map[string]string{}
+	for k, v := range m {
+		if k == SourceTemplateAnnotation {
+			continue
+		}
+		if IsOwnedMetadata(k, v) {
+			ret[k] = v
+		}
+	}
+	return ret
+}
+
+// IsOwnedMetadata returns whether the label or annotation key=value was set by dup
+func IsOwnedMetadata(key, value string) bool {
+	return strings.HasPrefix(key, MetadataPrefix) || (key == ManagedByLabel && value == ManagedByValue)
+}
+
+func mergeNestedStrings(obj map[string]interface{}, values map[string]string, path ...string) {
+	existing, _, _ := unstructured.NestedStringMap(obj, path...)
+	if existing == nil {
+		existing = map[string]string{}
+	}
+	for k, v := range values {
+		existing[k] = v
+	}
+	unstructured.SetNestedStringMap(obj, existing, path...)
+}
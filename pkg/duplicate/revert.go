@@ -0,0 +1,140 @@
+package duplicate
+
+import (
+	"reflect"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// ownershipLabels are the labels removed from duplicated pods
+var ownershipLabels = []string{"app.kubernetes.io/instance", "app.kubernetes.io/name"}
+
+// RevertMutations returns a copy of dup where the changes recorded in its
+// MutationsAnnotation are undone using the values of source. Fields that were
+// edited again after dup changed them are kept, so that only the changes made
+// by the user remain when dup is compared with source.
+func RevertMutations(dup, source *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	mutations, err := MutationsOf(dup)
+	if err != nil {
+		return nil, err
+	}
+	ret := dup.DeepCopy()
+	specPath := PodSpecPath(ret.GetKind())
+	for _, mutation := range mutations {
+		switch mutation.Type {
+		case MutationProbes:
+			restoreContainerFields(ret.Object, source.Object, specPath, func(field string, value interface{}) bool {
+				return (field == "readinessProbe" || field == "livenessProbe") && value == nil
+			})
+		case MutationLoopCommand:
+			loop := strings.Split(mutation.Value, " ")
+			restoreContainerFields(ret.Object, source.Object, specPath, func(field string, value interface{}) bool {
+				if field != "command" {
+					return false
+				}
+				command, _ := value.([]interface{})
+				return reflect.DeepEqual(toStrings(command), loop)
+			})
+		case MutationOwnership:
+			labels := ret.GetLabels()
+			for _, key := range ownershipLabels {
+				value, ok := source.GetLabels()[key]
+				if _, edited := labels[key]; ok && !edited {
+					if labels == nil {
+						labels = map[string]string{}
+					}
+					labels[key] = value
+				}
+			}
+			ret.SetLabels(labels)
+		case MutationTTL:
+			for _, path := range ttlPaths(ret.GetKind()) {
+				if value, found, _ := unstructured.NestedFieldCopy(source.Object, path...); found {
+					unstructured.SetNestedField(ret.Object, value, path...)
+				} else {
+					unstructured.RemoveNestedField(ret.Object, path...)
+				}
+			}
+		}
+	}
+	return ret, nil
+}
+
+// PodSpecPath returns the path of the pod spec of objects of kind
+func PodSpecPath(kind string) []string {
+	if kind == "Pod" {
+		return []string{"spec"}
+	}
+	path := PodTemplatePath(kind)
+	if path == nil {
+		return nil
+	}
+	return append(path, "spec")
+}
+
+// ttlPaths returns the paths of the deadlines set by applyTTL on objects of kind
+func ttlPaths(kind string) [][]string {
+	switch kind {
+	case "Pod":
+		return [][]string{{"spec", "activeDeadlineSeconds"}}
+	case "Job":
+		return [][]string{{"spec", "ttlSecondsAfterFinished"}}
+	case "CronJob":
+		return [][]string{{"spec", "jobTemplate", "spec", "ttlSecondsAfterFinished"}}
+	}
+	return nil
+}
+
+// restoreContainerFields copies the container fields selected by mutated from
+// the matching container of source, containers are matched by name.
+func restoreContainerFields(dup, source map[string]interface{}, specPath []string, mutated func(field string, value interface{}) bool) {
+	if specPath == nil {
+		return
+	}
+	path := append(append([]string{}, specPath...), "containers")
+	containers, found, _ := unstructured.NestedSlice(dup, path...)
+	if !found {
+		return
+	}
+	sourceContainers, _, _ := unstructured.NestedSlice(source, path...)
+	for i := range containers {
+		container, ok := containers[i].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		sourceContainer := findContainer(sourceContainers, container["name"])
+		if sourceContainer == nil {
+			continue
+		}
+		for _, field := range []string{"readinessProbe", "livenessProbe", "command"} {
+			if !mutated(field, container[field]) {
+				continue
+			}
+			if value, ok := sourceContainer[field]; ok {
+				container[field] = value
+			} else {
+				delete(container, field)
+			}
+		}
+	}
+	unstructured.SetNestedSlice(dup, containers, path...)
+}
+
+func findContainer(containers []interface{}, name interface{}) map[string]interface{} {
+	for _, c := range containers {
+		if container, ok := c.(map[string]interface{}); ok && container["name"] == name {
+			return container
+		}
+	}
+	return nil
+}
+
+func toStrings(values []interface{}) []string {
+	ret := make([]string, 0, len(values))
+	for _, v := range values {
+		s, _ := v.(string)
+		ret = append(ret, s)
+	}
+	return ret
+}
@@ -0,0 +1,124 @@
+package duplicate
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// SourceTemplateAnnotation holds the JSON pod template of the source as of
+// the creation or the last sync of a duplicate, the base of the next sync.
+const SourceTemplateAnnotation = MetadataPrefix + "source-template"
+
+// PodTemplatePath returns the path of the pod template of objects of kind, or
+// nil when kind has no pod template.
+func PodTemplatePath(kind string) []string {
+	paths := podTemplatePaths(kind)
+	if len(paths) == 0 {
+		return nil
+	}
+	path := paths[len(paths)-1]
+	return append([]string{}, path[:len(path)-1]...)
+}
+
+// recordSourceTemplate stores the pod template of source on dup
+func recordSourceTemplate(dup metav1.Object, source runtime.Object) error {
+	path := PodTemplatePath(source.GetObjectKind().GroupVersionKind().Kind)
+	if path == nil {
+		return nil
+	}
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(source)
+	if err != nil {
+		return err
+	}
+	return SetSourceTemplate(dup, &unstructured.Unstructured{Object: content})
+}
+
+// SetSourceTemplate records the current pod template of source on dup as the base of the next sync
+func SetSourceTemplate(dup metav1.Object, source *unstructured.Unstructured) error {
+	template, found, err := unstructured.NestedMap(source.Object, PodTemplatePath(source.GetKind())...)
+	if err != nil || !found {
+		return err
+	}
+	encoded, err := json.Marshal(template)
+	if err != nil {
+		return err
+	}
+	annotations := dup.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[SourceTemplateAnnotation] = string(encoded)
+	dup.SetAnnotations(annotations)
+	return nil
+}
+
+// SourceTemplateOf returns the pod template recorded on a duplicate, ok is
+// false for duplicates created before templates were recorded.
+func SourceTemplateOf(obj metav1.Object) (template map[string]interface{}, ok bool, err error) {
+	value, ok := obj.GetAnnotations()[SourceTemplateAnnotation]
+	if !ok {
+		return nil, false, nil
+	}
+	if err := json.Unmarshal([]byte(value), &template); err != nil {
+		return nil, false, fmt.Errorf("invalid %s annotation on %q: %v", SourceTemplateAnnotation, obj.GetName(), err)
+	}
+	return template, true, nil
+}
+
+// ReapplyMutations applies the recorded mutations of previous again to the
+// containers of updated, after its pod template was replaced. A container
+// only gets a mutation back if it was still in effect in previous, so that
+// later edits of the user win, containers new to updated always get it.
+func ReapplyMutations(updated, previous *unstructured.Unstructured) error {
+	mutations, err := MutationsOf(previous)
+	if err != nil {
+		return err
+	}
+	specPath := PodSpecPath(updated.GetKind())
+	if specPath == nil {
+		return nil
+	}
+	path := append(append([]string{}, specPath...), "containers")
+	containers, found, _ := unstructured.NestedSlice(updated.Object, path...)
+	if !found {
+		return nil
+	}
+	previousContainers, _, _ := unstructured.NestedSlice(previous.Object, path...)
+	for i := range containers {
+		container, ok := containers[i].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		previousContainer := findContainer(previousContainers, container["name"])
+		for _, mutation := range mutations {
+			switch mutation.Type {
+			case MutationProbes:
+				if previousContainer == nil || (previousContainer["readinessProbe"] == nil && previousContainer["livenessProbe"] == nil) {
+					delete(container, "readinessProbe")
+					delete(container, "livenessProbe")
+				}
+			case MutationLoopCommand:
+				loop := strings.Split(mutation.Value, " ")
+				command, _ := previousContainer["command"].([]interface{})
+				if previousContainer == nil || reflect.DeepEqual(toStrings(command), loop) {
+					container["command"] = toInterfaces(loop)
+				}
+			}
+		}
+	}
+	return unstructured.SetNestedSlice(updated.Object, containers, path...)
+}
+
+func toInterfaces(values []string) []interface{} {
+	ret := make([]interface{}, 0, len(values))
+	for _, v := range values {
+		ret = append(ret, v)
+	}
+	return ret
+}
@@ -0,0 +1,75 @@
+package duplicate
+
+import (
+	"fmt"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const (
+	// TTLLabel marks duplicates that expire, its value is the requested TTL
+	TTLLabel = MetadataPrefix + "ttl"
+	// ExpiresAtAnnotation holds the RFC3339 time after which a duplicate may be reaped
+	ExpiresAtAnnotation = MetadataPrefix + "expires-at"
+)
+
+// applyTTL stamps the expiry label and annotation on obj, and sets the
+// kind specific deadlines that let the cluster stop the duplicate by itself.
+func applyTTL(obj runtime.Object, ttl time.Duration, now time.Time) error {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return err
+	}
+	labels := accessor.GetLabels()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	labels[TTLLabel] = ttl.String()
+	accessor.SetLabels(labels)
+
+	annotations := accessor.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[ExpiresAtAnnotation] = now.Add(ttl).UTC().Format(time.RFC3339)
+	accessor.SetAnnotations(annotations)
+
+	seconds := int64(ttl.Seconds())
+	switch o := obj.(type) {
+	case *corev1.Pod:
+		o.Spec.ActiveDeadlineSeconds = &seconds
+	case *batchv1.Job:
+		o.Spec.TTLSecondsAfterFinished = toInt32(seconds)
+	case *batchv1.CronJob:
+		o.Spec.JobTemplate.Spec.TTLSecondsAfterFinished = toInt32(seconds)
+	}
+	return nil
+}
+
+// ExpiresAt returns the expiry time recorded on a duplicate, ok is false when
+// the object was created without a TTL.
+func ExpiresAt(obj metav1.Object) (expiresAt time.Time, ok bool, err error) {
+	value, ok := obj.GetAnnotations()[ExpiresAtAnnotation]
+	if !ok {
+		return time.Time{}, false, nil
+	}
+	expiresAt, err = time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("invalid %s annotation on %q: %v", ExpiresAtAnnotation, obj.GetName(), err)
+	}
+	return expiresAt, true, nil
+}
+
+func toInt32(seconds int64) *int32 {
+	const maxInt32 = int64(^uint32(0) >> 1)
+	if seconds > maxInt32 {
+		seconds = maxInt32
+	}
+	ret := int32(seconds)
+	return &ret
+}
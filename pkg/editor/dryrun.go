@@ -0,0 +1,176 @@
+package editor
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"dup/pkg/diff"
+	"dup/pkg/duplicate"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/rest"
+)
+
+// warningRecorder collects the warnings returned by the server while
+// recording, and hands them to out otherwise.
+type warningRecorder struct {
+	mu        sync.Mutex
+	recording bool
+	warnings  []string
+	out       rest.WarningHandler
+}
+
+// HandleWarningHeader implements rest.WarningHandler
+func (r *warningRecorder) HandleWarningHeader(code int, agent string, text string) {
+	if code != 299 || len(text) == 0 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.recording {
+		r.warnings = append(r.warnings, text)
+		return
+	}
+	r.out.HandleWarningHeader(code, agent, text)
+}
+
+func (r *warningRecorder) start() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.recording = true
+	r.warnings = nil
+}
+
+func (r *warningRecorder) stop() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.recording = false
+	warnings := r.warnings
+	r.warnings = nil
+	return warnings
+}
+
+// dryRunCreate sends info through a server side dry run create, or apply with
+// ServerSide, returning the object as admitted by the server and the warnings
+// it returned.
+func (o *EditOptions) dryRunCreate(info *resource.Info) (runtime.Object, []string, error) {
+	toCreate := info.Object.DeepCopyObject()
+	if u, ok := toCreate.(*unstructured.Unstructured); ok {
+		duplicate.Stamp(u, o.creator, o.group, time.Now())
+	}
+	o.warnings.start()
+	helper := resource.NewHelper(info.Client, info.Mapping).
+		DryRun(true).
+		WithFieldManager(o.FieldManager).
+		WithFieldValidation(o.ValidationDirective)
+	obj, err := o.send(helper, info, toCreate)
+	warnings := o.warnings.stop()
+	if err != nil {
+		return nil, warnings, err
+	}
+	return obj, warnings, nil
+}
+
+// dryRunReasons dry runs the creation of infos and describes the outcome as
+// header reasons, so that the user sees what admission does before editing.
+func (o *EditOptions) dryRunReasons(infos []*resource.Info) []editReason {
+	var reasons []editReason
+	for _, info := range infos {
+		name := fmt.Sprintf("%s %q", resourceString(info), info.Name)
+		obj, warnings, err := o.dryRunCreate(info)
+		if err != nil {
+			reasons = append(reasons, editReason{head: fmt.Sprintf("%s would be rejected by the server", name), other: []string{rejectionMessage(err)}})
+		}
+		if len(warnings) > 0 {
+			reasons = append(reasons, editReason{head: fmt.Sprintf("%s got warnings from the server", name), other: warnings})
+		}
+		if obj == nil {
+			continue
+		}
+		if changes := admissionChanges(info.Object, obj); len(changes) > 0 {
+			reasons = append(reasons, editReason{head: fmt.Sprintf("%s would be changed by admission webhooks", name), other: changes})
+		}
+	}
+	return reasons
+}
+
+// visitToDryRun dry runs the creation of every object. Rejections are
+// recorded in results when given, it returns whether any object was rejected.
+func (o *EditOptions) visitToDryRun(dryRunVisitor resource.Visitor, results *editResults) (bool, error) {
+	rejected := false
+	err := dryRunVisitor.Visit(func(info *resource.Info, incomingErr error) error {
+		obj, warnings, err := o.dryRunCreate(info)
+		for _, warning := range warnings {
+			fmt.Fprintf(o.ErrOut, "Warning: %s\n", warning)
+		}
+		if err != nil {
+			if results == nil {
+				return err
+			}
+			fmt.Fprintln(o.ErrOut, results.addError(err, info, o.editable(err, info)))
+			rejected = true
+			return nil
+		}
+		if !o.dryRunOnly() {
+			return nil
+		}
+		printer, err := o.ToPrinter(o.operation() + " (server dry run)")
+		if err != nil {
+			return err
+		}
+		return printer.PrintObj(obj, o.Out)
+	})
+	return rejected, err
+}
+
+// admissionChanges describes what the server changed in sent when admitting
+// it. Added map keys are left out as they are mostly server defaults, while
+// replaced or removed values, new list items and metadata are reported.
+func admissionChanges(sent, admitted runtime.Object) []string {
+	sentObj, ok := sent.(*unstructured.Unstructured)
+	if !ok {
+		return nil
+	}
+	admittedObj, ok := admitted.(*unstructured.Unstructured)
+	if !ok {
+		return nil
+	}
+	var changes []string
+	for _, op := range diff.JSONPatch(diff.Normalize(sentObj).Object, diff.Normalize(admittedObj).Object) {
+		switch {
+		case op.Op == "replace", op.Op == "remove":
+		case strings.HasSuffix(op.Path, "/-"), strings.Contains(op.Path, "/metadata/labels"), strings.Contains(op.Path, "/metadata/annotations"):
+		default:
+			continue
+		}
+		changes = append(changes, fmt.Sprintf("%s %s%s", op.Op, op.Path, describeValue(op.Value)))
+	}
+	return changes
+}
+
+// describeValue summarizes a patched value, by its name for list items
+func describeValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case map[string]interface{}:
+		if name, ok := v["name"].(string); ok {
+			return fmt.Sprintf(" (name: %s)", name)
+		}
+		return ""
+	case []interface{}:
+		return ""
+	}
+	return fmt.Sprintf(": %v", value)
+}
+
+func resourceString(info *resource.Info) string {
+	if len(info.Mapping.Resource.Group) > 0 {
+		return info.Mapping.Resource.Resource + "." + info.Mapping.Resource.Group
+	}
+	return info.Mapping.Resource.Resource
+}
@@ -19,19 +19,30 @@ package editor
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	goruntime "runtime"
 	"strings"
+	"time"
 
+	"dup/pkg/config"
 	"dup/pkg/duplicate"
+	"dup/pkg/interactive"
+	"dup/pkg/minimal"
+	"dup/pkg/mutate"
+	"dup/pkg/preserved"
+	"dup/pkg/session"
+	duputil "dup/pkg/util"
 
 	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/google/uuid"
 	"github.com/spf13/cobra"
 	"k8s.io/klog/v2"
 
+	authorizationv1 "k8s.io/api/authorization/v1"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
@@ -46,10 +57,12 @@ import (
 	"k8s.io/cli-runtime/pkg/printers"
 	"k8s.io/cli-runtime/pkg/resource"
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
+	"k8s.io/client-go/rest"
 	cmdutil "k8s.io/kubectl/pkg/cmd/util"
 	"k8s.io/kubectl/pkg/cmd/util/editor/crlf"
 	"k8s.io/kubectl/pkg/scheme"
 	"k8s.io/kubectl/pkg/util"
+	"k8s.io/kubectl/pkg/util/term"
 )
 
 var SupportedSubresources = []string{"status"}
@@ -63,7 +76,20 @@ type EditOptions struct {
 	OutputPatch        bool
 	WindowsLineEndings bool
 	SkipEdit           bool
-	DuplicateOptions   *duplicate.PodOptions
+	// Interactive shows menus changing the pod specs before the editor
+	Interactive bool
+	// Minimal hides the fields equal to their defaults in the editor
+	Minimal bool
+	// SideBySide opens the sources read-only next to the duplicates
+	SideBySide bool
+	// ResumeFile is a preserved edit session to edit again instead of duplicating
+	ResumeFile string
+	// Profile names the profile of the config file providing default options
+	Profile          string
+	EditSeparately   bool
+	DuplicateOptions *duplicate.PodOptions
+	SessionOptions   *session.Options
+	MutateOptions    *mutate.Options
 
 	cmdutil.ValidateOptions
 	ValidationDirective string
@@ -83,8 +109,37 @@ type EditOptions struct {
 	updatedResultGetter func(data []byte) *resource.Result
 
 	FieldManager string
+	// ServerSide creates and updates the duplicates through server-side apply
+	ServerSide bool
 
 	Subresource string
+
+	DryRunStrategy cmdutil.DryRunStrategy
+	// OutputDir receives the final manifests instead of creating them
+	OutputDir string
+	// EmitKustomize receives a kustomize overlay of the sources instead of creating the duplicates
+	EmitKustomize string
+	// warnings captures the server warnings of dry runs
+	warnings *warningRecorder
+
+	// resumed holds the content of ResumeFile, parsed into resumedInfos when valid
+	resumed      []byte
+	resumedInfos []*resource.Info
+	// resumeReasons tell why the resumed file couldn't be parsed
+	resumeReasons []editReason
+	// resumedArgs are the arguments of the command that started the resumed session
+	resumedArgs []string
+	// diffEditors are the diff templates of the config file
+	diffEditors map[string]string
+	// mutateReasons hold the reports of KRM functions, shown in the first editor header
+	mutateReasons []editReason
+	// sources holds the objects the duplicates were cloned from
+	sources []*resource.Info
+	// creator and group are recorded on every duplicate as its provenance
+	creator string
+	group   string
+	// created holds every object created by this invocation
+	created []*resource.Info
 }
 
 type DuplicateOptions struct {
@@ -110,8 +165,11 @@ func NewEditOptions(ioStreams genericiooptions.IOStreams) *EditOptions {
 
 		WindowsLineEndings: goruntime.GOOS == "windows",
 
+		FieldManager:     duplicate.ManagedByValue,
 		IOStreams:        ioStreams,
 		DuplicateOptions: &duplicate.PodOptions{},
+		SessionOptions:   session.NewOptions(ioStreams),
+		MutateOptions:    mutate.NewOptions(ioStreams),
 	}
 }
 
@@ -170,6 +228,93 @@ func (o *EditOptions) Complete(f cmdutil.Factory, args []string, cmd *cobra.Comm
 		return err
 	}
 
+	o.DryRunStrategy, err = cmdutil.GetDryRunStrategy(cmd)
+	if err != nil {
+		return err
+	}
+	if len(o.OutputDir) > 0 && o.DryRunStrategy == cmdutil.DryRunServer {
+		return fmt.Errorf("--output-dir can't be combined with --dry-run=server")
+	}
+	if len(o.EmitKustomize) > 0 && (len(o.OutputDir) > 0 || o.DryRunStrategy != cmdutil.DryRunNone) {
+		return fmt.Errorf("--emit-kustomize can't be combined with --output-dir or --dry-run")
+	}
+	if o.ServerSide && o.exportOnly() {
+		return fmt.Errorf("--server-side can't be combined with --dry-run=client, --output-dir or --emit-kustomize")
+	}
+	if o.SideBySide && o.SkipEdit {
+		return fmt.Errorf("--side-by-side can't be combined with --skip-edit")
+	}
+	o.warnings = &warningRecorder{out: rest.NewWarningWriter(o.ErrOut, rest.WarningWriterOptions{Deduplicate: true})}
+	rest.SetDefaultWarningHandler(o.warnings)
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	if len(o.Profile) > 0 {
+		profile, err := cfg.Profile(o.Profile)
+		if err != nil {
+			return err
+		}
+		o.applyProfile(cmd, profile)
+	}
+	if len(cfg.Untrusted) > 0 {
+		path, _ := config.Path()
+		fmt.Fprintf(o.ErrOut, "Warning: ignoring the transforms, functions and diff editors of %s, the transforms, functions and patch files of its profiles, and its profiles named like yours; add its directory to trustedRepos in %s to use them\n", cfg.Untrusted, path)
+	}
+	o.diffEditors = cfg.DiffEditors
+	o.MutateOptions.Transforms = append(cfg.Transforms, o.MutateOptions.Transforms...)
+	o.MutateOptions.Functions = append(cfg.Functions, o.MutateOptions.Functions...)
+	if err := o.MutateOptions.Validate(); err != nil {
+		return err
+	}
+	if err := o.SessionOptions.Validate(); err != nil {
+		return err
+	}
+	if err := o.SessionOptions.Complete(f, o.CmdNamespace); err != nil {
+		return err
+	}
+	// an interrupted client can't clean up, let gc reap the duplicates instead
+	if o.SessionOptions.Remove && o.DuplicateOptions.TTL == 0 {
+		o.DuplicateOptions.TTL = session.DefaultRemoveTTL
+	}
+
+	o.updatedResultGetter = func(data []byte) *resource.Result {
+		// resource builder to read objects from edited data
+		return f.NewBuilder().
+			Unstructured().
+			Stream(bytes.NewReader(data), "edited-file").
+			ContinueOnError().
+			Flatten().
+			Do()
+	}
+
+	if len(o.ResumeFile) > 0 {
+		err = o.completeResume(f)
+	} else {
+		err = o.completeDuplicates(f, args)
+	}
+	if err != nil {
+		return err
+	}
+
+	o.creator = duputil.WhoAmI(f)
+	// a resumed session keeps the group of the duplicates it already created
+	if len(o.group) == 0 {
+		o.group = uuid.New().String()
+	}
+
+	o.ToPrinter = func(operation string) (printers.ResourcePrinter, error) {
+		o.PrintFlags.NamePrintFlags.Operation = operation
+		return o.PrintFlags.ToPrinter()
+	}
+
+	return nil
+}
+
+// completeDuplicates fetches the objects named by args and clones them into
+// the duplicates to edit, changed by the mutate options and interactive menus.
+func (o *EditOptions) completeDuplicates(f cmdutil.Factory, args []string) error {
 	b := f.NewBuilder().
 		Unstructured().
 		ResourceTypeOrNameArgs(true, args...).
@@ -178,7 +323,7 @@ func (o *EditOptions) Complete(f cmdutil.Factory, args []string, cmd *cobra.Comm
 		Flatten().
 		Do()
 
-	err = b.Err()
+	err := b.Err()
 	if err != nil {
 		return err
 	}
@@ -188,10 +333,26 @@ func (o *EditOptions) Complete(f cmdutil.Factory, args []string, cmd *cobra.Comm
 		return err
 	}
 
+	o.sources = objects
 	resources, err := duplicate.Clone(o.DuplicateOptions, objects)
 	if err != nil {
 		return err
 	}
+	resources, reports, err := o.MutateOptions.Apply(resources, objects, o.DuplicateOptions)
+	if err != nil {
+		return err
+	}
+	if err := o.addMutateReports(reports); err != nil {
+		return err
+	}
+	if o.Interactive {
+		if !(term.TTY{In: o.In}).IsTerminalIn() {
+			return fmt.Errorf("--interactive requires a terminal")
+		}
+		if err := interactive.Run(o.IOStreams, resources); err != nil {
+			return err
+		}
+	}
 
 	resourceObjects, err := objsBody(resources)
 	if err != nil {
@@ -203,26 +364,12 @@ func (o *EditOptions) Complete(f cmdutil.Factory, args []string, cmd *cobra.Comm
 	}
 
 	o.OriginalResult = result
-
-	o.updatedResultGetter = func(data []byte) *resource.Result {
-		// resource builder to read objects from edited data
-		return f.NewBuilder().
-			Unstructured().
-			Stream(bytes.NewReader(data), "edited-file").
-			ContinueOnError().
-			Flatten().
-			Do()
-	}
-
-	o.ToPrinter = func(operation string) (printers.ResourcePrinter, error) {
-		o.PrintFlags.NamePrintFlags.Operation = operation
-		return o.PrintFlags.ToPrinter()
-	}
-
 	return nil
 }
 
-func (o *EditOptions) createResources(obj []*resource.Info) error {
+// createResources creates obj on the server. With results, objects rejected by
+// the server are recorded there to be edited again instead of failing.
+func (o *EditOptions) createResources(obj []*resource.Info, results *editResults) error {
 	visitor := resource.InfoListVisitor(obj)
 
 	if err := o.restoreManagedFields(obj); err != nil {
@@ -239,7 +386,28 @@ func (o *EditOptions) createResources(obj []*resource.Info) error {
 		return err
 	}
 
-	err := o.visitToCreate(visitor)
+	if len(o.EmitKustomize) > 0 {
+		return o.visitToKustomize(visitor)
+	}
+	if o.exportOnly() {
+		return o.visitToExport(visitor)
+	}
+
+	// nothing is created unless every object passes a server dry run
+	rejected, err := o.visitToDryRun(visitor, results)
+	if err != nil {
+		return err
+	}
+	if rejected {
+		// all objects are edited again since none was created
+		results.edit = obj
+		return nil
+	}
+	if o.dryRunOnly() {
+		return nil
+	}
+
+	err = o.visitToCreate(visitor, results)
 	if err != nil {
 		return err
 	}
@@ -249,8 +417,14 @@ func (o *EditOptions) createResources(obj []*resource.Info) error {
 // Run performs the execution
 func (o *EditOptions) Run() error {
 	//	CreateDuplicatePod(context.Background(), ioStreams, clientset, deployment, namespace, podName, edit)
-	edit := NewDefaultEditor(editorEnvs())
-	// editFn is invoked for each edit session (once with a list for normal edit, once for each individual resource in a edit-on-create invocation)
+	edit := NewDefaultEditor(EditorEnvs())
+	if o.SideBySide {
+		var err error
+		if edit, err = edit.WithDiffTemplate(o.diffEditors); err != nil {
+			return err
+		}
+	}
+	// editFn is invoked for each edit session, once with every duplicate or once per duplicate with EditSeparately
 	editFn := func(obj []*resource.Info) error {
 		var (
 			results = editResults{}
@@ -259,11 +433,42 @@ func (o *EditOptions) Run() error {
 			err     error
 		)
 
+		// the duplicates are summarized at the top of every header of this session
+		var summary []editReason
+		if o.editPrinterOptions.addHeader {
+			summary = o.summaryReasons(obj)
+		}
+		results.header.summary = summary
+
+		// function reports and the outcome of a dry run are shown before the first edit
+		results.header.reasons = append(append([]editReason{}, o.resumeReasons...), o.mutateReasons...)
+		if !o.exportOnly() {
+			results.header.reasons = append(results.header.reasons, o.dryRunReasons(obj)...)
+		}
+
+		// the sources are opened read-only next to the duplicates
+		var originals []string
+		if o.SideBySide {
+			original, err := o.writeOriginal(obj)
+			if err != nil {
+				return err
+			}
+			if len(original) > 0 {
+				defer os.Remove(original)
+				originals = append(originals, original)
+			}
+		}
+
 		containsError := false
+		// a resumed session starts from the preserved file, even unchanged it is tried again
+		resuming := o.resumed != nil
+		if resuming {
+			edited, containsError = o.resumed, true
+		}
 		// loop until we succeed or cancel editing
 		for {
 			// get the object we're going to serialize as input to the editor
-			originalObj := obj[0].Object
+			originalObj := editObject(obj)
 
 			// generate the file to edit
 			buf := &bytes.Buffer{}
@@ -278,10 +483,10 @@ func (o *EditOptions) Run() error {
 
 			if !containsError {
 				if err := o.extractManagedFields(originalObj); err != nil {
-					return preservedFile(err, results.file, o.ErrOut)
+					return o.preserve(err, results.file)
 				}
-				if err := o.editPrinterOptions.PrintObj(originalObj, w); err != nil {
-					return preservedFile(err, results.file, o.ErrOut)
+				if err := o.editPrinterOptions.PrintObj(o.viewObject(originalObj), w); err != nil {
+					return o.preserve(err, results.file)
 				}
 			} else {
 				// In case of an error, preserve the edited file.
@@ -292,41 +497,25 @@ func (o *EditOptions) Run() error {
 
 			// launch the editor
 			editedDiff := edited
-			edited, file, err = edit.LaunchTempFile(fmt.Sprintf("%s-edit-", filepath.Base(os.Args[0])), o.editPrinterOptions.ext, buf)
+			edited, file, err = edit.LaunchTempFile(fmt.Sprintf("%s-edit-", filepath.Base(os.Args[0])), o.editPrinterOptions.ext, buf, originals...)
 			if err != nil {
-				return preservedFile(err, results.file, o.ErrOut)
+				return o.preserve(err, results.file)
 			}
 
 			// If we're retrying the loop because of an error, and no change was made in the file, short-circuit
-			if containsError && bytes.Equal(cmdutil.StripComments(editedDiff), cmdutil.StripComments(edited)) {
-				return preservedFile(fmt.Errorf("%s", "Edit cancelled, no valid changes were saved."), file, o.ErrOut)
+			if containsError && !resuming && bytes.Equal(cmdutil.StripComments(editedDiff), cmdutil.StripComments(edited)) {
+				return o.preserve(fmt.Errorf("%s", "Edit cancelled, no valid changes were saved."), file)
 			}
+			resuming = false
 			// cleanup any file from the previous pass
 			if len(results.file) > 0 {
 				os.Remove(results.file)
 			}
 			klog.V(4).Infof("User edited:\n%s", string(edited))
 
-			// Apply validation
-			schema, err := o.f.Validator(o.ValidationDirective)
-			if err != nil {
-				return preservedFile(err, file, o.ErrOut)
-			}
-
-			err = schema.ValidateBytes(cmdutil.StripComments(edited))
-			if err != nil {
-				results = editResults{
-					file: file,
-				}
-				containsError = true
-				fmt.Fprintln(o.ErrOut, results.addError(apierrors.NewInvalid(corev1.SchemeGroupVersion.WithKind("").GroupKind(),
-					"", field.ErrorList{field.Invalid(nil, "The edited file failed validation", fmt.Sprintf("%v", err))}), obj[0]))
-				continue
-			}
-
 			lines, err := hasLines(bytes.NewBuffer(edited))
 			if err != nil {
-				return preservedFile(err, file, o.ErrOut)
+				return o.preserve(err, file)
 			}
 			if !lines {
 				os.Remove(file)
@@ -335,10 +524,11 @@ func (o *EditOptions) Run() error {
 			}
 
 			results = editResults{
-				file: file,
+				header: editHeader{summary: summary},
+				file:   file,
 			}
 
-			// parse the edited file
+			// parse the edited file, objects removed from it are not created
 			updatedInfos, err := o.updatedResultGetter(edited).Infos()
 			if err != nil {
 				// syntax error
@@ -347,44 +537,211 @@ func (o *EditOptions) Run() error {
 				continue
 			}
 
+			// the minimal view left out the defaults, put them back before validating
+			if o.Minimal {
+				for _, info := range updatedInfos {
+					if u, ok := info.Object.(*unstructured.Unstructured); ok {
+						minimal.Restore(u)
+					}
+				}
+			}
+
+			// Apply validation to every object so errors are reported per object
+			if err := o.validateEdited(updatedInfos, &results); err != nil {
+				return o.preserve(err, file)
+			}
+			if len(results.edit) > 0 {
+				containsError = true
+				continue
+			}
+
 			containsError = false
 
 			// restore managed fields to original object
 			if err := o.restoreManagedFields(obj); err != nil {
-				return preservedFile(err, file, o.ErrOut)
+				return o.preserve(err, file)
 			}
 
-			err = o.createResources(updatedInfos)
+			err = o.createResources(updatedInfos, &results)
 			if err != nil {
-				return preservedFile(err, results.file, o.ErrOut)
+				return o.preserve(err, results.file)
 			}
 
-			if len(results.edit) == 0 {
-				if results.notfound == 0 {
-					os.Remove(file)
-				} else {
-					fmt.Fprintf(o.Out, "The edits you made on deleted resources have been saved to %q\n", file)
+			if len(results.failed) > 0 {
+				// editing can't help, the objects that were not created are kept to be resumed
+				if err := o.saveNotCreated(file, updatedInfos); err != nil {
+					return o.preserve(err, file)
 				}
+				fmt.Fprintf(o.ErrOut, "The objects that were not created have been saved to %q\n", file)
+				o.record(file)
+				return cmdutil.ErrExit
+			}
+			if len(results.edit) == 0 {
+				os.Remove(file)
 				return nil
 			}
 
-			if len(results.header.reasons) > 0 {
-				containsError = true
+			// reopen the editor with the rejected objects only, the others were created
+			edited, err = o.editedBody(results.edit)
+			if err != nil {
+				return o.preserve(err, file)
 			}
+			containsError = true
 		}
 	}
-	return o.OriginalResult.Visit(func(info *resource.Info, err error) error {
-		objects := []*resource.Info{info}
-
-		if o.SkipEdit {
-			err := o.createResources(objects)
-			if err != nil {
-				return err
+	// there are no original objects when resuming
+	var (
+		infos []*resource.Info
+		err   error
+	)
+	if o.OriginalResult != nil {
+		if infos, err = o.OriginalResult.Infos(); err != nil {
+			return err
+		}
+	}
+	switch {
+	case o.resumed != nil:
+		err = editFn(o.resumedInfos)
+	case o.SkipEdit:
+		err = o.createResources(infos, nil)
+	case o.EditSeparately:
+		for _, info := range infos {
+			if err = editFn([]*resource.Info{info}); err != nil {
+				break
 			}
-			return nil
 		}
-		return editFn(objects)
-	})
+	default:
+		err = editFn(infos)
+	}
+	if err != nil {
+		return err
+	}
+	if len(o.ResumeFile) > 0 {
+		if err := preserved.Remove(o.ResumeFile); err != nil {
+			return err
+		}
+	}
+
+	if o.SessionOptions.Enabled() && len(o.created) > 0 {
+		return o.SessionOptions.Run(o.group, o.created)
+	}
+	return nil
+}
+
+// applyProfile sets the options of profile, unless given as flags. Lists of
+// the profile come before those given as flags.
+func (o *EditOptions) applyProfile(cmd *cobra.Command, profile *config.Profile) {
+	flags := cmd.Flags()
+	setBool := func(name string, target *bool, value *bool) {
+		if value != nil && !flags.Changed(name) {
+			*target = *value
+		}
+	}
+	setBool("pod", &o.DuplicateOptions.DuplicateInnerPod, profile.Pod)
+	setBool("disable-probes", &o.DuplicateOptions.DisableProbes, profile.DisableProbes)
+	setBool("command-loop", &o.DuplicateOptions.LoopCommand, profile.CommandLoop)
+	setBool("skip-edit", &o.SkipEdit, profile.SkipEdit)
+	if profile.ParsedTTL > 0 && !flags.Changed("ttl") {
+		o.DuplicateOptions.TTL = profile.ParsedTTL
+	}
+	if len(profile.PatchType) > 0 && !flags.Changed("patch-type") {
+		o.MutateOptions.PatchType = profile.PatchType
+	}
+	m := o.MutateOptions
+	m.Set = append(append([]string{}, profile.Set...), m.Set...)
+	m.Patches = append(append([]string{}, profile.Patches...), m.Patches...)
+	m.PatchFiles = append(append([]string{}, profile.PatchFiles...), m.PatchFiles...)
+	m.Transforms = append(append([]string{}, profile.Transforms...), m.Transforms...)
+	m.Functions = append(append([]mutate.Function{}, profile.Functions...), m.Functions...)
+}
+
+// addMutateReports keeps the reports of KRM functions for the editor header.
+// Without an editor they are printed, and a failed function is an error.
+func (o *EditOptions) addMutateReports(reports []mutate.Report) error {
+	for _, report := range reports {
+		if !o.SkipEdit {
+			o.mutateReasons = append(o.mutateReasons, editReason{head: report.Head, other: report.Details})
+			continue
+		}
+		if report.Failed {
+			return fmt.Errorf("%s:\n%s", report.Head, strings.Join(report.Details, "\n"))
+		}
+		fmt.Fprintf(o.ErrOut, "%s:\n", report.Head)
+		for _, detail := range report.Details {
+			fmt.Fprintf(o.ErrOut, "  %s\n", detail)
+		}
+	}
+	return nil
+}
+
+// dryRunOnly returns whether objects are only sent through a server dry run
+func (o *EditOptions) dryRunOnly() bool {
+	return o.DryRunStrategy == cmdutil.DryRunServer
+}
+
+// editObject returns the object presented in the editor for infos, a List when there are several
+func editObject(infos []*resource.Info) runtime.Object {
+	if len(infos) == 1 {
+		return infos[0].Object
+	}
+	l := &unstructured.UnstructuredList{
+		Object: map[string]interface{}{
+			"kind":       "List",
+			"apiVersion": "v1",
+			"metadata":   map[string]interface{}{},
+		},
+	}
+	for _, info := range infos {
+		l.Items = append(l.Items, *info.Object.(*unstructured.Unstructured))
+	}
+	return l
+}
+
+// viewObject returns obj as shown in the editor, a minimized copy with Minimal
+func (o *EditOptions) viewObject(obj runtime.Object) runtime.Object {
+	if !o.Minimal {
+		return obj
+	}
+	obj = obj.DeepCopyObject()
+	switch v := obj.(type) {
+	case *unstructured.Unstructured:
+		minimal.Minimize(v)
+	case *unstructured.UnstructuredList:
+		for i := range v.Items {
+			minimal.Minimize(&v.Items[i])
+		}
+	}
+	return obj
+}
+
+// editedBody serializes infos the way they are presented in the editor
+func (o *EditOptions) editedBody(infos []*resource.Info) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	if err := o.editPrinterOptions.PrintObj(o.viewObject(editObject(infos)), buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// validateEdited validates every edited object against the schema, adding
+// a reason to the header of results for each invalid one.
+func (o *EditOptions) validateEdited(infos []*resource.Info, results *editResults) error {
+	schema, err := o.f.Validator(o.ValidationDirective)
+	if err != nil {
+		return err
+	}
+	for _, info := range infos {
+		data, err := runtime.Encode(unstructured.UnstructuredJSONScheme, info.Object)
+		if err != nil {
+			return err
+		}
+		if err := schema.ValidateBytes(data); err != nil {
+			gk := info.Object.GetObjectKind().GroupVersionKind().GroupKind()
+			fmt.Fprintln(o.ErrOut, results.addError(apierrors.NewInvalid(gk, info.Name,
+				field.ErrorList{field.Invalid(nil, "The edited object failed validation", fmt.Sprintf("%v", err))}), info, true))
+		}
+	}
+	return nil
 }
 
 func (o *EditOptions) Build(reader io.Reader, validate string) (*resource.Result, error) {
@@ -525,17 +882,27 @@ func encodeToJSON(obj runtime.Unstructured) ([]byte, error) {
 	return js, nil
 }
 
-func (o *EditOptions) visitToCreate(createVisitor resource.Visitor) error {
+func (o *EditOptions) visitToCreate(createVisitor resource.Visitor, results *editResults) error {
 	err := createVisitor.Visit(func(info *resource.Info, incomingErr error) error {
-		obj, err := resource.NewHelper(info.Client, info.Mapping).
+		// stamp a copy, so a rejected object is edited again as the user left it
+		toCreate := info.Object.DeepCopyObject()
+		if u, ok := toCreate.(*unstructured.Unstructured); ok {
+			duplicate.Stamp(u, o.creator, o.group, time.Now())
+		}
+		helper := resource.NewHelper(info.Client, info.Mapping).
 			WithFieldManager(o.FieldManager).
-			WithFieldValidation(o.ValidationDirective).
-			Create(info.Namespace, true, info.Object)
+			WithFieldValidation(o.ValidationDirective)
+		obj, err := o.send(helper, info, toCreate)
 		if err != nil {
-			return err
+			if results == nil {
+				return err
+			}
+			fmt.Fprintln(o.ErrOut, results.addError(err, info, o.editable(err, info)))
+			return nil
 		}
 		info.Refresh(obj, true)
-		printer, err := o.ToPrinter("created")
+		o.created = append(o.created, info)
+		printer, err := o.ToPrinter(o.operation())
 		if err != nil {
 			return err
 		}
@@ -544,6 +911,38 @@ func (o *EditOptions) visitToCreate(createVisitor resource.Visitor) error {
 	return err
 }
 
+// send creates obj, or applies it with ServerSide so that an existing
+// duplicate of the same name converges instead of failing with AlreadyExists.
+func (o *EditOptions) send(helper *resource.Helper, info *resource.Info, obj runtime.Object) (runtime.Object, error) {
+	if !o.ServerSide {
+		return helper.Create(info.Namespace, true, obj)
+	}
+	// apply configurations can't carry managed fields, nor the identity of
+	// the source the duplicate was cloned from
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		u = u.DeepCopy()
+		duplicate.RemoveServerMetadata(u)
+		obj = u
+	} else if accessor, err := meta.Accessor(obj); err == nil {
+		accessor.SetManagedFields(nil)
+		accessor.SetUID("")
+		accessor.SetResourceVersion("")
+	}
+	data, err := runtime.Encode(unstructured.UnstructuredJSONScheme, obj)
+	if err != nil {
+		return nil, err
+	}
+	return helper.Patch(info.Namespace, info.Name, types.ApplyPatchType, data, nil)
+}
+
+// operation describes how objects are sent to the server in printed results
+func (o *EditOptions) operation() string {
+	if o.ServerSide {
+		return "serverside-applied"
+	}
+	return "created"
+}
+
 func (o *EditOptions) visitAnnotation(annotationVisitor resource.Visitor) error {
 	// iterate through all items to apply annotations
 	err := annotationVisitor.Visit(func(info *resource.Info, incomingErr error) error {
@@ -566,8 +965,9 @@ type editReason struct {
 	other []string
 }
 
-// editHeader includes a list of reasons the edit must be retried
+// editHeader includes a summary of the duplicates and a list of reasons the edit must be retried
 type editHeader struct {
+	summary []editReason
 	reasons []editReason
 }
 
@@ -578,7 +978,7 @@ func (h *editHeader) writeTo(w io.Writer) error {
 # reopened with the relevant failures.
 #
 `)
-	for _, r := range h.reasons {
+	for _, r := range append(append([]editReason{}, h.summary...), h.reasons...) {
 		if len(r.other) > 0 {
 			fmt.Fprintf(w, "# %s:\n", hashOnLineBreak(r.head))
 		} else {
@@ -592,20 +992,37 @@ func (h *editHeader) writeTo(w io.Writer) error {
 	return nil
 }
 
+// saveNotCreated overwrites file with the objects of infos that were not created
+func (o *EditOptions) saveNotCreated(file string, infos []*resource.Info) error {
+	var notCreated []*resource.Info
+	for _, info := range infos {
+		created := false
+		for _, c := range o.created {
+			created = created || c == info
+		}
+		if !created {
+			notCreated = append(notCreated, info)
+		}
+	}
+	body, err := o.editedBody(notCreated)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(file, body, 0o600)
+}
+
 // editResults capture the result of an update
 type editResults struct {
-	header    editHeader
-	retryable int
-	notfound  int
-	edit      []*resource.Info
-	file      string
+	header editHeader
+	// failed holds the objects rejected for reasons editing can't fix
+	failed []*resource.Info
+	edit   []*resource.Info
+	file   string
 }
 
-func (r *editResults) addError(err error, info *resource.Info) string {
-	resourceString := info.Mapping.Resource.Resource
-	if len(info.Mapping.Resource.Group) > 0 {
-		resourceString = resourceString + "." + info.Mapping.Resource.Group
-	}
+// addError records the rejection of info, to be edited again when editable
+func (r *editResults) addError(err error, info *resource.Info, editable bool) string {
+	resourceString := resourceString(info)
 
 	switch {
 	case apierrors.IsInvalid(err):
@@ -622,12 +1039,95 @@ func (r *editResults) addError(err error, info *resource.Info) string {
 		}
 		r.header.reasons = append(r.header.reasons, reason)
 		return fmt.Sprintf("error: %s %q is invalid", resourceString, info.Name)
-	case apierrors.IsNotFound(err):
-		r.notfound++
-		return fmt.Sprintf("error: %s %q could not be found on the server", resourceString, info.Name)
+	case editable:
+		r.edit = append(r.edit, info)
+		r.header.reasons = append(r.header.reasons, editReason{
+			head:  fmt.Sprintf("%s %q was rejected by the server", resourceString, info.Name),
+			other: []string{rejectionMessage(err)},
+		})
+		return fmt.Sprintf("error: %s %q could not be created: %v", resourceString, info.Name, err)
 	default:
-		r.retryable++
-		return fmt.Sprintf("error: %s %q could not be patched: %v", resourceString, info.Name, err)
+		// such as a missing namespace or permission, editing can't help
+		r.failed = append(r.failed, info)
+		return fmt.Sprintf("error: %s %q could not be created: %v", resourceString, info.Name, err)
+	}
+}
+
+// editable returns whether the rejection of info may be fixed by editing
+// it: the object is invalid or malformed, its name is taken, or it was
+// forbidden while the user is allowed to send it, by admission or a quota.
+func (o *EditOptions) editable(err error, info *resource.Info) bool {
+	switch {
+	case apierrors.IsInvalid(err), apierrors.IsBadRequest(err), apierrors.IsAlreadyExists(err), apierrors.IsConflict(err):
+		return true
+	case apierrors.IsForbidden(err):
+		return o.allowed(info)
+	}
+	return false
+}
+
+// allowed returns whether the user may send info the way o does, false when
+// the server can't tell
+func (o *EditOptions) allowed(info *resource.Info) bool {
+	client, err := o.f.KubernetesClientSet()
+	if err != nil || info.Mapping == nil {
+		return false
+	}
+	verb := "create"
+	if o.ServerSide {
+		verb = "patch"
+	}
+	review, err := client.AuthorizationV1().SelfSubjectAccessReviews().Create(context.TODO(), &authorizationv1.SelfSubjectAccessReview{
+		Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace: info.Namespace,
+				Verb:      verb,
+				Group:     info.Mapping.Resource.Group,
+				Resource:  info.Mapping.Resource.Resource,
+			},
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		klog.V(2).Infof("Unable to review the access to %s: %v", info.Mapping.Resource, err)
+		return false
+	}
+	return review.Status.Allowed
+}
+
+// rejectionMessage returns the message of an API error, without the object identification already in the header
+func rejectionMessage(err error) string {
+	if status, ok := err.(apierrors.APIStatus); ok && len(status.Status().Message) > 0 {
+		return status.Status().Message
+	}
+	return err.Error()
+}
+
+// preserve reports that the edited file was kept at path, and records it so
+// that 'kubectl dup resume' can edit it again.
+func (o *EditOptions) preserve(err error, path string) error {
+	err = preservedFile(err, path, o.ErrOut)
+	if _, statErr := os.Stat(path); len(path) > 0 && statErr == nil {
+		o.record(path)
+	}
+	return err
+}
+
+// record tracks path as a preserved session of the current command, it
+// replaces the session being resumed.
+func (o *EditOptions) record(path string) {
+	args := o.resumedArgs
+	if args == nil {
+		args = os.Args[1:]
+	}
+	if err := preserved.Save(path, args, o.group, time.Now()); err != nil {
+		klog.V(2).Infof("Unable to record the preserved file %s: %v", path, err)
+		return
+	}
+	fmt.Fprintln(o.ErrOut, "Run 'kubectl dup resume' to edit it again.")
+	if len(o.ResumeFile) > 0 && o.ResumeFile != path {
+		if err := preserved.Remove(o.ResumeFile); err != nil {
+			klog.V(2).Infof("Unable to remove the resumed file %s: %v", o.ResumeFile, err)
+		}
 	}
 }
 
@@ -676,8 +1176,8 @@ func hashOnLineBreak(s string) string {
 	return r
 }
 
-// editorEnvs returns an ordered list of env vars to check for editor preferences.
-func editorEnvs() []string {
+// EditorEnvs returns an ordered list of env vars to check for editor preferences.
+func EditorEnvs() []string {
 	return []string{
 		"KUBE_EDITOR",
 		"EDITOR",
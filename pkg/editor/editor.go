@@ -25,6 +25,7 @@ import (
 	"runtime"
 	"strings"
 
+	"github.com/google/shlex"
 	"k8s.io/klog/v2"
 
 	"k8s.io/kubectl/pkg/util/term"
@@ -41,10 +42,26 @@ const (
 	windowsShell  = "cmd"
 )
 
+// DiffTemplates hold the arguments given to editors with a diff mode to
+// compare files, keyed by the name of the editor command. {1}, {2}... stand
+// for the paths of the files.
+var DiffTemplates = map[string]string{
+	"vim":     "-d {1} {2}",
+	"nvim":    "-d {1} {2}",
+	"gvim":    "-d -f {1} {2}",
+	"vimdiff": "{1} {2}",
+	"code":    "--diff --wait {1} {2}",
+	"codium":  "--diff --wait {1} {2}",
+	"meld":    "{1} {2}",
+}
+
 // Editor holds the command-line args to fire up the editor
 type Editor struct {
 	Args  []string
 	Shell bool
+	// Template lays out the paths given to the editor, they are appended
+	// to Args without it.
+	Template []string
 }
 
 // NewDefaultEditor creates a struct Editor that uses the OS environment to
@@ -96,27 +113,80 @@ func defaultEnvEditor(envs []string) ([]string, bool) {
 	return append(shell, editor), true
 }
 
-func (e Editor) args(path string) []string {
+// WithDiffTemplate returns e laid out by the diff template of its command,
+// templates overriding DiffTemplates. Editors without a diff mode are
+// returned as they are, and open the paths one after the other.
+func (e Editor) WithDiffTemplate(templates map[string]string) (Editor, error) {
+	name := e.command()
+	template, ok := templates[name]
+	if !ok {
+		template, ok = DiffTemplates[name]
+	}
+	if !ok {
+		return e, nil
+	}
+	args, err := shlex.Split(template)
+	if err != nil {
+		return e, fmt.Errorf("invalid diff template %q of %s: %v", template, name, err)
+	}
+	e.Template = args
+	return e, nil
+}
+
+// command returns the name of the editor program, without its directory
+func (e Editor) command() string {
+	if len(e.Args) == 0 {
+		return ""
+	}
+	command := e.Args[0]
+	if e.Shell {
+		words, err := shlex.Split(e.Args[len(e.Args)-1])
+		if err != nil || len(words) == 0 {
+			return ""
+		}
+		command = words[0]
+	}
+	return strings.TrimSuffix(filepath.Base(command), ".exe")
+}
+
+func (e Editor) args(paths []string) []string {
+	params := paths
+	if len(e.Template) > 0 {
+		params = make([]string, 0, len(e.Template))
+		for _, param := range e.Template {
+			for i, path := range paths {
+				param = strings.ReplaceAll(param, fmt.Sprintf("{%d}", i+1), path)
+			}
+			params = append(params, param)
+		}
+	}
 	args := make([]string, len(e.Args))
 	copy(args, e.Args)
 	if e.Shell {
 		last := args[len(args)-1]
-		args[len(args)-1] = fmt.Sprintf("%s %q", last, path)
+		for _, param := range params {
+			last = fmt.Sprintf("%s %q", last, param)
+		}
+		args[len(args)-1] = last
 	} else {
-		args = append(args, path)
+		args = append(args, params...)
 	}
 	return args
 }
 
 // Launch opens the described or returns an error. The TTY will be protected, and
 // SIGQUIT, SIGTERM, and SIGINT will all be trapped.
-func (e Editor) Launch(path string) error {
+func (e Editor) Launch(paths ...string) error {
 	if len(e.Args) == 0 {
-		return fmt.Errorf("no editor defined, can't open %s", path)
+		return fmt.Errorf("no editor defined, can't open %s", strings.Join(paths, ", "))
 	}
-	abs, err := filepath.Abs(path)
-	if err != nil {
-		return err
+	abs := make([]string, 0, len(paths))
+	for _, path := range paths {
+		p, err := filepath.Abs(path)
+		if err != nil {
+			return err
+		}
+		abs = append(abs, p)
 	}
 	args := e.args(abs)
 	cmd := exec.Command(args[0], args[1:]...)
@@ -138,8 +208,9 @@ func (e Editor) Launch(path string) error {
 // LaunchTempFile reads the provided stream into a temporary file in the given directory
 // and file prefix, and then invokes Launch with the path of that file. It will return
 // the contents of the file after launch, any errors that occur, and the path of the
-// temporary file so the caller can clean it up as needed.
-func (e Editor) LaunchTempFile(prefix, suffix string, r io.Reader) ([]byte, string, error) {
+// temporary file so the caller can clean it up as needed. The paths of
+// others are opened before it, such as a read-only original to compare with.
+func (e Editor) LaunchTempFile(prefix, suffix string, r io.Reader, others ...string) ([]byte, string, error) {
 	f, err := os.CreateTemp("", prefix+"*"+suffix)
 	if err != nil {
 		return nil, "", err
@@ -152,7 +223,13 @@ func (e Editor) LaunchTempFile(prefix, suffix string, r io.Reader) ([]byte, stri
 	}
 	// This file descriptor needs to close so the next process (Launch) can claim it.
 	f.Close()
-	if err := e.Launch(path); err != nil {
+	// editors laid out by a template compare others with the file, the
+	// others open the file first
+	paths := append([]string{path}, others...)
+	if len(e.Template) > 0 {
+		paths = append(append([]string{}, others...), path)
+	}
+	if err := e.Launch(paths...); err != nil {
 		return nil, path, err
 	}
 	bytes, err := os.ReadFile(path)
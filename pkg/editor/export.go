@@ -0,0 +1,79 @@
+package editor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"dup/pkg/duplicate"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/cli-runtime/pkg/printers"
+	"k8s.io/cli-runtime/pkg/resource"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+)
+
+// exportOnly returns whether the duplicates are written out instead of created
+func (o *EditOptions) exportOnly() bool {
+	return o.DryRunStrategy == cmdutil.DryRunClient || len(o.OutputDir) > 0 || len(o.EmitKustomize) > 0
+}
+
+// visitToExport prints every object, or writes it to a file in o.OutputDir
+func (o *EditOptions) visitToExport(exportVisitor resource.Visitor) error {
+	if len(o.OutputDir) > 0 {
+		if err := os.MkdirAll(o.OutputDir, 0o755); err != nil {
+			return err
+		}
+	}
+	now := time.Now()
+	return exportVisitor.Visit(func(info *resource.Info, incomingErr error) error {
+		obj := exportObject(info.Object, o.creator, o.group, now)
+		if len(o.OutputDir) == 0 {
+			printer, err := o.ToPrinter("created (dry run)")
+			if err != nil {
+				return err
+			}
+			return printer.PrintObj(obj, o.Out)
+		}
+		return o.writeManifest(info, obj)
+	})
+}
+
+// writeManifest writes obj to <kind>-<name>.<format> in o.OutputDir
+func (o *EditOptions) writeManifest(info *resource.Info, obj runtime.Object) error {
+	var (
+		printer printers.ResourcePrinter = &printers.YAMLPrinter{}
+		ext                              = "yaml"
+	)
+	if *o.PrintFlags.OutputFormat == "json" {
+		printer, ext = &printers.JSONPrinter{}, "json"
+	}
+	kind := strings.ToLower(obj.GetObjectKind().GroupVersionKind().Kind)
+	path := filepath.Join(o.OutputDir, fmt.Sprintf("%s-%s.%s", kind, info.Name, ext))
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := printer.PrintObj(obj, f); err != nil {
+		return err
+	}
+	fmt.Fprintf(o.Out, "%s/%s written to %s\n", kind, info.Name, path)
+	return f.Close()
+}
+
+// exportObject returns a copy of obj stamped like a created duplicate,
+// without the fields populated by the server.
+func exportObject(obj runtime.Object, creator, group string, now time.Time) runtime.Object {
+	u, ok := obj.DeepCopyObject().(*unstructured.Unstructured)
+	if !ok {
+		return obj
+	}
+	duplicate.Stamp(u, creator, group, now)
+	duplicate.RemoveServerMetadata(u)
+	delete(u.Object, "status")
+	return u
+}
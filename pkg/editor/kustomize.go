@@ -0,0 +1,334 @@
+package editor
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"dup/pkg/duplicate"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/kubectl/pkg/scheme"
+	kustomizeutil "sigs.k8s.io/kustomize/api/pkg/util"
+	"sigs.k8s.io/kustomize/api/types"
+	"sigs.k8s.io/kustomize/kyaml/resid"
+	kyaml "sigs.k8s.io/kustomize/kyaml/yaml"
+	"sigs.k8s.io/yaml"
+)
+
+// kustomizeBase is the directory, relative to the overlay, holding the sources
+const kustomizeBase = "base"
+
+// overlay accumulates the kustomization expressing the duplicates
+type overlay struct {
+	kustomization types.Kustomization
+	base          types.Kustomization
+	// suffixes holds the name suffix of every duplicate, a shared one becomes nameSuffix
+	suffixes map[string]bool
+	renames  []types.Patch
+	images   map[string]types.Image
+}
+
+// visitToKustomize writes a kustomize overlay to o.EmitKustomize, with the
+// sources as its base and the changes of every duplicate as patches, images
+// and replicas.
+func (o *EditOptions) visitToKustomize(kustomizeVisitor resource.Visitor) error {
+	dir := o.EmitKustomize
+	if err := os.MkdirAll(filepath.Join(dir, kustomizeBase), 0o755); err != nil {
+		return err
+	}
+	ov := newOverlay()
+	now := time.Now()
+	err := kustomizeVisitor.Visit(func(info *resource.Info, incomingErr error) error {
+		dup, ok := exportObject(info.Object, o.creator, o.group, now).(*unstructured.Unstructured)
+		if !ok {
+			return fmt.Errorf("unexpected object type %T", info.Object)
+		}
+		source, err := o.sourceOf(dup)
+		if err != nil {
+			return err
+		}
+		return ov.add(dir, source, dup)
+	})
+	if err != nil {
+		return err
+	}
+	ov.finish()
+	path, err := ov.write(dir)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(o.Out, "kustomize overlay written to %s\n", path)
+	return nil
+}
+
+func newOverlay() *overlay {
+	return &overlay{
+		kustomization: types.Kustomization{TypeMeta: types.TypeMeta{APIVersion: types.KustomizationVersion, Kind: types.KustomizationKind}},
+		base:          types.Kustomization{TypeMeta: types.TypeMeta{APIVersion: types.KustomizationVersion, Kind: types.KustomizationKind}},
+		suffixes:      map[string]bool{},
+		images:        map[string]types.Image{},
+	}
+}
+
+// sourceOf returns the cleaned up source object of dup
+func (o *EditOptions) sourceOf(dup *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	source, ok, err := duplicate.SourceOf(dup)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("%s %q has no source, objects added while editing can't be expressed as an overlay", dup.GetKind(), dup.GetName())
+	}
+	info, err := o.findSource(source)
+	if err != nil {
+		return nil, err
+	}
+	if info == nil {
+		return nil, fmt.Errorf("source %s of %q not found", source, dup.GetName())
+	}
+	u, ok := info.Object.(*unstructured.Unstructured)
+	if !ok {
+		return nil, fmt.Errorf("unexpected object type %T", info.Object)
+	}
+	u = u.DeepCopy()
+	duplicate.RemoveServerMetadata(u)
+	delete(u.Object, "status")
+	return u, nil
+}
+
+// findSource returns the info of source among the objects that were
+// duplicated, or nil when it isn't one of them.
+func (o *EditOptions) findSource(source duplicate.Source) (*resource.Info, error) {
+	for _, info := range o.sources {
+		accessor, err := meta.Accessor(info.Object)
+		if err != nil {
+			return nil, err
+		}
+		if info.Object.GetObjectKind().GroupVersionKind().Kind == source.Kind && accessor.GetName() == source.Name {
+			return info, nil
+		}
+	}
+	return nil, nil
+}
+
+// add writes source to the base and the changes of dup as patches of the overlay
+func (ov *overlay) add(dir string, source, dup *unstructured.Unstructured) error {
+	kind := strings.ToLower(source.GetKind())
+	baseFile := fmt.Sprintf("%s-%s.yaml", kind, source.GetName())
+	if err := writeYAML(filepath.Join(dir, kustomizeBase, baseFile), source.Object); err != nil {
+		return err
+	}
+	ov.base.Resources = append(ov.base.Resources, baseFile)
+
+	// images and replicas have their own transformers, the patch keeps the rest
+	patched := dup.DeepCopy()
+	if err := ov.extractImages(source, patched); err != nil {
+		return err
+	}
+	if replicas, found, _ := unstructured.NestedInt64(patched.Object, "spec", "replicas"); found {
+		sourceReplicas, sourceFound, _ := unstructured.NestedInt64(source.Object, "spec", "replicas")
+		if !sourceFound || replicas != sourceReplicas {
+			ov.kustomization.Replicas = append(ov.kustomization.Replicas, types.Replica{Name: source.GetName(), Count: replicas})
+			if sourceFound {
+				unstructured.SetNestedField(patched.Object, sourceReplicas, "spec", "replicas")
+			} else {
+				unstructured.RemoveNestedField(patched.Object, "spec", "replicas")
+			}
+		}
+	}
+	// the name changes through nameSuffix or a rename, the patch targets the source
+	if suffix, ok := strings.CutPrefix(dup.GetName(), source.GetName()); ok && len(suffix) > 0 {
+		ov.suffixes[suffix] = true
+	} else {
+		ov.suffixes[""] = true
+	}
+	patched.SetName(source.GetName())
+
+	patch, err := strategicPatch(source, patched)
+	if err != nil {
+		return err
+	}
+	patchFile := fmt.Sprintf("patch-%s-%s.yaml", kind, source.GetName())
+	if err := writeYAML(filepath.Join(dir, patchFile), patch); err != nil {
+		return err
+	}
+	gvk := source.GroupVersionKind()
+	ov.kustomization.Patches = append(ov.kustomization.Patches, types.Patch{Path: patchFile})
+	ov.renames = append(ov.renames, types.Patch{
+		Patch:   fmt.Sprintf("- op: replace\n  path: /metadata/name\n  value: %s\n", dup.GetName()),
+		Target:  &types.Selector{ResId: resid.ResId{Gvk: resid.Gvk{Group: gvk.Group, Version: gvk.Version, Kind: gvk.Kind}, Name: source.GetName()}},
+		Options: map[string]bool{"allowNameChange": true},
+	})
+	return nil
+}
+
+// extractImages records the changed container images of dup as kustomize
+// images, and puts the images of source back into dup.
+func (ov *overlay) extractImages(source, dup *unstructured.Unstructured) error {
+	specPath := duplicate.PodSpecPath(source.GetKind())
+	if specPath == nil {
+		return nil
+	}
+	for _, field := range []string{"initContainers", "containers"} {
+		path := append(append([]string{}, specPath...), field)
+		containers, found, _ := unstructured.NestedSlice(dup.Object, path...)
+		if !found {
+			continue
+		}
+		sourceContainers, _, _ := unstructured.NestedSlice(source.Object, path...)
+		for i := range containers {
+			container, ok := containers[i].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			sourceImage := containerImage(sourceContainers, container["name"])
+			image, _ := container["image"].(string)
+			if len(sourceImage) == 0 || image == sourceImage {
+				continue
+			}
+			name, _, _ := kustomizeutil.SplitImageName(sourceImage)
+			newName, newTag, digest := kustomizeutil.SplitImageName(image)
+			entry := types.Image{Name: name, NewTag: newTag, Digest: digest}
+			if newName != name {
+				entry.NewName = newName
+			}
+			if previous, ok := ov.images[name]; ok && !reflect.DeepEqual(previous, entry) {
+				return fmt.Errorf("image %s is changed to different images across duplicates, which can't be expressed as kustomize images", name)
+			}
+			ov.images[name] = entry
+			container["image"] = sourceImage
+		}
+		if err := unstructured.SetNestedSlice(dup.Object, containers, path...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// finish names the duplicates with nameSuffix when they all share one, and
+// with a rename patch per duplicate otherwise.
+func (ov *overlay) finish() {
+	ov.kustomization.Resources = []string{kustomizeBase}
+	if len(ov.suffixes) == 1 && !ov.suffixes[""] {
+		for suffix := range ov.suffixes {
+			ov.kustomization.NameSuffix = suffix
+		}
+	} else {
+		ov.kustomization.Patches = append(ov.kustomization.Patches, ov.renames...)
+	}
+	names := make([]string, 0, len(ov.images))
+	for name := range ov.images {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		ov.kustomization.Images = append(ov.kustomization.Images, ov.images[name])
+	}
+}
+
+// write writes the kustomizations of the overlay and its base to dir, and
+// returns the path of the overlay one
+func (ov *overlay) write(dir string) (string, error) {
+	if err := writeYAML(filepath.Join(dir, kustomizeBase, "kustomization.yaml"), &ov.base); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, "kustomization.yaml")
+	return path, writeYAML(path, &ov.kustomization)
+}
+
+// strategicPatch returns the patch turning source into dup, identified like
+// source so kustomize can match it, or a merge patch for unregistered kinds.
+func strategicPatch(source, dup *unstructured.Unstructured) (map[string]interface{}, error) {
+	sourceJSON, err := json.Marshal(source.Object)
+	if err != nil {
+		return nil, err
+	}
+	dupJSON, err := json.Marshal(dup.Object)
+	if err != nil {
+		return nil, err
+	}
+	var patchJSON []byte
+	if versionedObject, err := scheme.Scheme.New(source.GroupVersionKind()); err == nil {
+		patchJSON, err = strategicpatch.CreateTwoWayMergePatch(sourceJSON, dupJSON, versionedObject)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		patchJSON, err = jsonpatch.CreateMergePatch(sourceJSON, dupJSON)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var patch map[string]interface{}
+	if err := json.Unmarshal(patchJSON, &patch); err != nil {
+		return nil, err
+	}
+	// the order of list items does not change, kustomize does not need the directives
+	dropOrderDirectives(patch)
+	patch["apiVersion"] = source.GetAPIVersion()
+	patch["kind"] = source.GetKind()
+	metadata, _ := patch["metadata"].(map[string]interface{})
+	if metadata == nil {
+		metadata = map[string]interface{}{}
+	}
+	metadata["name"] = source.GetName()
+	if len(source.GetNamespace()) > 0 {
+		metadata["namespace"] = source.GetNamespace()
+	}
+	patch["metadata"] = metadata
+	return patch, nil
+}
+
+func dropOrderDirectives(value interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if strings.HasPrefix(key, "$setElementOrder/") {
+				delete(v, key)
+				continue
+			}
+			dropOrderDirectives(child)
+		}
+	case []interface{}:
+		for _, child := range v {
+			dropOrderDirectives(child)
+		}
+	}
+}
+
+func containerImage(containers []interface{}, name interface{}) string {
+	for _, c := range containers {
+		if container, ok := c.(map[string]interface{}); ok && container["name"] == name {
+			image, _ := container["image"].(string)
+			return image
+		}
+	}
+	return ""
+}
+
+// writeYAML writes a kustomization with kyaml, keeping its field order, and anything else as sorted YAML
+func writeYAML(path string, value interface{}) error {
+	var (
+		data []byte
+		err  error
+	)
+	if k, ok := value.(*types.Kustomization); ok {
+		data, err = kyaml.Marshal(k)
+	} else {
+		data, err = yaml.Marshal(value)
+	}
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
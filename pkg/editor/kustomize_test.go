@@ -0,0 +1,161 @@
+package editor
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/kustomize/api/krusty"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+)
+
+// workload describes a deployment of the tests
+type workload struct {
+	name     string
+	image    string
+	replicas int64
+	probes   bool
+}
+
+func (w workload) object() *unstructured.Unstructured {
+	container := map[string]interface{}{
+		"name":  "app",
+		"image": w.image,
+		"env":   []interface{}{map[string]interface{}{"name": "MODE", "value": "prod"}},
+	}
+	if w.probes {
+		container["readinessProbe"] = map[string]interface{}{"httpGet": map[string]interface{}{"path": "/ready", "port": int64(8080)}}
+		container["livenessProbe"] = map[string]interface{}{"httpGet": map[string]interface{}{"path": "/live", "port": int64(8080)}}
+	}
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]interface{}{"name": w.name, "namespace": "shop"},
+		"spec": map[string]interface{}{
+			"replicas": w.replicas,
+			"selector": map[string]interface{}{"matchLabels": map[string]interface{}{"app": "web"}},
+			"template": map[string]interface{}{
+				"metadata": map[string]interface{}{"labels": map[string]interface{}{"app": "web"}},
+				"spec": map[string]interface{}{
+					"containers": []interface{}{
+						container,
+						map[string]interface{}{"name": "proxy", "image": "proxy:v1"},
+					},
+				},
+			},
+		},
+	}}
+}
+
+// normalized returns content as decoded from JSON, for kustomize and
+// unstructured content to compare equal
+func normalized(t *testing.T, content map[string]interface{}) interface{} {
+	t.Helper()
+	data, err := json.Marshal(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ret interface{}
+	if err := json.Unmarshal(data, &ret); err != nil {
+		t.Fatal(err)
+	}
+	return ret
+}
+
+func TestOverlayRendersDuplicates(t *testing.T) {
+	web := workload{name: "web", image: "registry.example.com/web:v1", replicas: 3, probes: true}
+	api := workload{name: "api", image: "api:v1", replicas: 2, probes: true}
+	with := func(w workload, change func(*workload)) workload {
+		change(&w)
+		return w
+	}
+
+	tests := []struct {
+		name string
+		// pairs hold a source and its duplicate
+		pairs [][2]workload
+		// suffix is the nameSuffix expected of the overlay
+		suffix string
+	}{
+		{
+			name: "shared suffix",
+			pairs: [][2]workload{
+				{web, with(web, func(w *workload) { w.name = "web-debug" })},
+				{api, with(api, func(w *workload) { w.name = "api-debug" })},
+			},
+			suffix: "-debug",
+		},
+		{
+			name: "mixed names",
+			pairs: [][2]workload{
+				{web, with(web, func(w *workload) { w.name = "web-debug" })},
+				{api, with(api, func(w *workload) { w.name = "debug-api" })},
+			},
+		},
+		{
+			name: "image change",
+			pairs: [][2]workload{
+				{web, with(web, func(w *workload) { w.name, w.image = "web-dup", "registry.example.com/web:v2" })},
+				{api, with(api, func(w *workload) { w.name, w.image = "api-dup", "mirror.example.com/api@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef" })},
+			},
+			suffix: "-dup",
+		},
+		{
+			name: "replicas change",
+			pairs: [][2]workload{
+				{web, with(web, func(w *workload) { w.name, w.replicas = "web-dup", 1 })},
+			},
+			suffix: "-dup",
+		},
+		{
+			name: "removed probes",
+			pairs: [][2]workload{
+				{web, with(web, func(w *workload) { w.name, w.probes = "web-dup", false })},
+			},
+			suffix: "-dup",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.MkdirAll(filepath.Join(dir, kustomizeBase), 0o755); err != nil {
+				t.Fatal(err)
+			}
+			ov := newOverlay()
+			want := map[string]interface{}{}
+			for _, pair := range tt.pairs {
+				dup := pair[1].object()
+				want[dup.GetName()] = normalized(t, dup.Object)
+				if err := ov.add(dir, pair[0].object(), dup); err != nil {
+					t.Fatal(err)
+				}
+			}
+			ov.finish()
+			if ov.kustomization.NameSuffix != tt.suffix {
+				t.Errorf("expected the name suffix %q, got %q", tt.suffix, ov.kustomization.NameSuffix)
+			}
+			if _, err := ov.write(dir); err != nil {
+				t.Fatal(err)
+			}
+
+			resources, err := krusty.MakeKustomizer(krusty.MakeDefaultOptions()).Run(filesys.MakeFsOnDisk(), dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := map[string]interface{}{}
+			for _, r := range resources.Resources() {
+				content, err := r.Map()
+				if err != nil {
+					t.Fatal(err)
+				}
+				got[r.GetName()] = normalized(t, content)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("expected the overlay to render\n%v\ngot\n%v", want, got)
+			}
+		})
+	}
+}
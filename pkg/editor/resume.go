@@ -0,0 +1,77 @@
+package editor
+
+import (
+	"fmt"
+	"os"
+
+	"dup/pkg/duplicate"
+	"dup/pkg/preserved"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/klog/v2"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+)
+
+// completeResume loads the preserved file of ResumeFile to edit it again. The
+// duplicates it holds are parsed to summarize them and fetch their sources, a
+// file that doesn't parse is edited as it is.
+func (o *EditOptions) completeResume(f cmdutil.Factory) error {
+	record, err := preserved.Find(o.ResumeFile)
+	if err != nil {
+		return err
+	}
+	o.resumedArgs, o.group = record.Args, record.Group
+	if o.resumed, err = os.ReadFile(o.ResumeFile); err != nil {
+		return err
+	}
+	infos, err := o.updatedResultGetter(o.resumed).Infos()
+	if err != nil {
+		o.resumeReasons = append(o.resumeReasons, editReason{head: fmt.Sprintf("The resumed file has a syntax error: %v", err)})
+		return nil
+	}
+	o.resumedInfos = infos
+	o.sources = o.fetchSources(f, infos)
+	return nil
+}
+
+// fetchSources gets the current state of the sources of the duplicates of
+// infos, sources that can't be found are left out.
+func (o *EditOptions) fetchSources(f cmdutil.Factory, infos []*resource.Info) []*resource.Info {
+	var sources []*resource.Info
+	seen := map[duplicate.Source]bool{}
+	for _, info := range infos {
+		accessor, err := meta.Accessor(info.Object)
+		if err != nil {
+			continue
+		}
+		source, ok, err := duplicate.SourceOf(accessor)
+		if err != nil || !ok || seen[source] {
+			continue
+		}
+		seen[source] = true
+
+		resourceType := source.Kind
+		if gv, err := schema.ParseGroupVersion(source.APIVersion); err == nil && len(gv.Group) > 0 {
+			resourceType = fmt.Sprintf("%s.%s.%s", source.Kind, gv.Version, gv.Group)
+		}
+		namespace := source.Namespace
+		if len(namespace) == 0 {
+			namespace = o.CmdNamespace
+		}
+		found, err := f.NewBuilder().
+			Unstructured().
+			NamespaceParam(namespace).DefaultNamespace().
+			ResourceTypeOrNameArgs(true, resourceType+"/"+source.Name).
+			Flatten().
+			Do().
+			Infos()
+		if err != nil {
+			klog.V(2).Infof("Unable to get the source %s: %v", source, err)
+			continue
+		}
+		sources = append(sources, found...)
+	}
+	return sources
+}
@@ -0,0 +1,87 @@
+package editor
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"dup/pkg/duplicate"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/kubectl/pkg/cmd/util/editor/crlf"
+)
+
+// writeOriginal writes the sources of the duplicates of infos to a read-only
+// temporary file, in the same order and shape as the duplicates are shown in
+// the editor so that they line up in a diff. A duplicate without a source,
+// such as an object added by a function, gets a placeholder holding only its
+// kind and name. The path is empty when none of the duplicates has a source.
+func (o *EditOptions) writeOriginal(infos []*resource.Info) (string, error) {
+	var sources []*resource.Info
+	found := false
+	for _, info := range infos {
+		source, err := o.originalOf(info)
+		if err == nil && source != nil {
+			found = true
+		} else {
+			source = placeholder(info)
+		}
+		sources = append(sources, &resource.Info{Object: source})
+	}
+	if !found {
+		return "", nil
+	}
+
+	buf := &bytes.Buffer{}
+	var w io.Writer = buf
+	if o.WindowsLineEndings {
+		w = crlf.NewCRLFWriter(w)
+	}
+	if o.editPrinterOptions.addHeader {
+		fmt.Fprint(w, `# Read-only copy of the objects being duplicated, for reference.
+# Changes to this file are ignored.
+#
+`)
+	}
+	if err := o.editPrinterOptions.PrintObj(o.viewObject(editObject(sources)), w); err != nil {
+		return "", err
+	}
+
+	f, err := os.CreateTemp("", fmt.Sprintf("%s-original-*%s", filepath.Base(os.Args[0]), o.editPrinterOptions.ext))
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if _, err := io.Copy(f, buf); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	if err := f.Chmod(0o444); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// originalOf returns the source of the duplicate of info, nil when it has none
+func (o *EditOptions) originalOf(info *resource.Info) (*unstructured.Unstructured, error) {
+	u, ok := info.Object.(*unstructured.Unstructured)
+	if !ok {
+		return nil, nil
+	}
+	if _, ok, err := duplicate.SourceOf(u); err != nil || !ok {
+		return nil, err
+	}
+	return o.sourceOf(u)
+}
+
+// placeholder stands for the missing source of info
+func placeholder(info *resource.Info) *unstructured.Unstructured {
+	ret := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	ret.GetObjectKind().SetGroupVersionKind(info.Object.GetObjectKind().GroupVersionKind())
+	ret.SetName(info.Name)
+	return ret
+}
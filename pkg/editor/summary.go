@@ -0,0 +1,128 @@
+package editor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"dup/pkg/duplicate"
+	duputil "dup/pkg/util"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/klog/v2"
+)
+
+// maxSourcePods bounds the pods of a source workload listed in the header
+const maxSourcePods = 3
+
+// summaryReasons describes, for each duplicate of infos, the object it was
+// cloned from with its current status and every change dup applied to it.
+func (o *EditOptions) summaryReasons(infos []*resource.Info) []editReason {
+	var reasons []editReason
+	now := time.Now()
+	for _, info := range infos {
+		accessor, err := meta.Accessor(info.Object)
+		if err != nil {
+			continue
+		}
+		source, ok, err := duplicate.SourceOf(accessor)
+		if err != nil || !ok {
+			continue
+		}
+		reason := editReason{head: fmt.Sprintf("%s %q duplicates %s", resourceString(info), info.Name, source)}
+		sourceInfo, err := o.findSource(source)
+		if err == nil && sourceInfo != nil {
+			reason.other = append(reason.other, o.sourceStatus(sourceInfo, now)...)
+		}
+		mutations, _ := duplicate.MutationsOf(accessor)
+		for _, mutation := range mutations {
+			reason.other = append(reason.other, "applied: "+mutation.String())
+		}
+		reasons = append(reasons, reason)
+	}
+	return reasons
+}
+
+// sourceStatus describes the status of the source of a duplicate, along with
+// its failing pods when it is a workload.
+func (o *EditOptions) sourceStatus(info *resource.Info, now time.Time) []string {
+	u, ok := info.Object.(*unstructured.Unstructured)
+	if !ok {
+		return nil
+	}
+	status := "status: " + duputil.WorkloadStatus(u)
+	if u.GetKind() == "Pod" {
+		pod := &corev1.Pod{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, pod); err == nil {
+			if termination := duputil.LastTermination(pod, now); len(termination) > 0 {
+				status += ", " + termination
+			}
+		}
+		return []string{status}
+	}
+
+	lines := []string{status}
+	pods, err := o.sourcePods(u)
+	if err != nil {
+		klog.V(2).Infof("Unable to list the pods of %s %q: %v", u.GetKind(), u.GetName(), err)
+		return lines
+	}
+	failing := 0
+	for i := range pods {
+		pod := &pods[i]
+		podStatus := duputil.PodStatus(pod)
+		termination := duputil.LastTermination(pod, now)
+		if (podStatus == string(corev1.PodRunning) || podStatus == "Completed") && len(termination) == 0 {
+			continue
+		}
+		failing++
+		if failing > maxSourcePods {
+			continue
+		}
+		line := fmt.Sprintf("pod %s: %s", pod.Name, podStatus)
+		if len(termination) > 0 {
+			line += ", " + termination
+		}
+		lines = append(lines, line)
+	}
+	if failing > maxSourcePods {
+		lines = append(lines, fmt.Sprintf("and %d more pods", failing-maxSourcePods))
+	}
+	return lines
+}
+
+// sourcePods lists the pods matching the selector of the workload u, none
+// when it has no selector.
+func (o *EditOptions) sourcePods(u *unstructured.Unstructured) ([]corev1.Pod, error) {
+	value, found, err := unstructured.NestedMap(u.Object, "spec", "selector")
+	if err != nil || !found {
+		return nil, err
+	}
+	labelSelector := &metav1.LabelSelector{}
+	if u.GetKind() == "ReplicationController" {
+		labelSelector.MatchLabels, _, err = unstructured.NestedStringMap(value)
+	} else {
+		err = runtime.DefaultUnstructuredConverter.FromUnstructured(value, labelSelector)
+	}
+	if err != nil {
+		return nil, err
+	}
+	selector, err := metav1.LabelSelectorAsSelector(labelSelector)
+	if err != nil || selector.Empty() {
+		return nil, err
+	}
+	client, err := o.f.KubernetesClientSet()
+	if err != nil {
+		return nil, err
+	}
+	list, err := client.CoreV1().Pods(u.GetNamespace()).List(context.Background(), metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
@@ -0,0 +1,266 @@
+package interactive
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/google/shlex"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// containerMenu edits a container until the user goes back
+func (p *prompter) containerMenu(spec *corev1.PodSpec, ref containerRef) error {
+	c := ref.container
+	// probes removed in this menu can be put back
+	removed := map[string]*corev1.Probe{}
+	for {
+		fmt.Fprintf(p.out, "\nContainer %s\n", c.Name)
+		fmt.Fprintf(p.out, "  1) image: %s\n", c.Image)
+		fmt.Fprintf(p.out, "  2) command: %s\n", commandSummary(c))
+		fmt.Fprintf(p.out, "  3) probes: %s\n", probesSummary(c))
+		fmt.Fprintf(p.out, "  4) env: %d\n", len(c.Env))
+		fmt.Fprintf(p.out, "  5) resources: %s\n", resourcesSummary(c.Resources))
+		fmt.Fprintln(p.out, "  r) remove the container from the duplicate")
+		answer, err := p.ask("Select a field, b to go back: ")
+		if err != nil {
+			return err
+		}
+		switch answer {
+		case "b", "":
+			return nil
+		case "1":
+			if c.Image, err = p.askDefault("Image", c.Image); err != nil {
+				return err
+			}
+		case "2":
+			err = p.editCommand(c)
+		case "3":
+			err = p.toggleProbes(c, removed)
+		case "4":
+			err = p.envMenu(c)
+		case "5":
+			err = p.editResources(c)
+		case "r":
+			if !ref.init && len(spec.Containers) == 1 {
+				fmt.Fprintln(p.out, "The only container can't be removed")
+				continue
+			}
+			removeContainer(spec, c.Name, ref.init)
+			return nil
+		default:
+			fmt.Fprintf(p.out, "Unknown choice %q\n", answer)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (p *prompter) editCommand(c *corev1.Container) error {
+	answer, err := p.askDefault("Command, - for the image default", commandSummary(c))
+	if err != nil || answer == commandSummary(c) {
+		return err
+	}
+	if len(answer) == 0 {
+		c.Command, c.Args = nil, nil
+		return nil
+	}
+	words, err := shlex.Split(answer)
+	if err != nil {
+		fmt.Fprintf(p.out, "Invalid command: %v\n", err)
+		return nil
+	}
+	c.Command, c.Args = words, nil
+	return nil
+}
+
+// toggleProbes removes a probe, or puts back one removed before
+func (p *prompter) toggleProbes(c *corev1.Container, removed map[string]*corev1.Probe) error {
+	probes := []struct {
+		name  string
+		probe **corev1.Probe
+	}{
+		{"readiness", &c.ReadinessProbe},
+		{"liveness", &c.LivenessProbe},
+		{"startup", &c.StartupProbe},
+	}
+	for i, pr := range probes {
+		state := "none"
+		switch {
+		case *pr.probe != nil:
+			state = "enabled"
+		case removed[pr.name] != nil:
+			state = "removed"
+		}
+		fmt.Fprintf(p.out, "  %d) %s: %s\n", i+1, pr.name, state)
+	}
+	answer, err := p.ask("Select a probe to toggle, a to remove all, b to go back: ")
+	if err != nil {
+		return err
+	}
+	for i, pr := range probes {
+		if answer != "a" && answer != strconv.Itoa(i+1) {
+			continue
+		}
+		if *pr.probe != nil {
+			removed[pr.name], *pr.probe = *pr.probe, nil
+		} else if answer != "a" {
+			*pr.probe, removed[pr.name] = removed[pr.name], nil
+		}
+	}
+	return nil
+}
+
+// envMenu adds, changes and removes environment variables
+func (p *prompter) envMenu(c *corev1.Container) error {
+	for {
+		for i, env := range c.Env {
+			fmt.Fprintf(p.out, "  %d) %s\n", i+1, envSummary(env))
+		}
+		answer, err := p.ask("NAME=VALUE to set a variable, a number to change it, -NUMBER to remove it, b to go back: ")
+		if err != nil {
+			return err
+		}
+		switch {
+		case answer == "b" || answer == "":
+			return nil
+		case strings.Contains(answer, "="):
+			name, value, _ := strings.Cut(answer, "=")
+			setEnv(c, strings.TrimSpace(name), value)
+			continue
+		}
+		remove := strings.HasPrefix(answer, "-")
+		i, err := strconv.Atoi(strings.TrimPrefix(answer, "-"))
+		if err != nil || i < 1 || i > len(c.Env) {
+			fmt.Fprintf(p.out, "Unknown choice %q\n", answer)
+			continue
+		}
+		if remove {
+			c.Env = append(c.Env[:i-1], c.Env[i:]...)
+			continue
+		}
+		env := &c.Env[i-1]
+		value, err := p.askDefault(env.Name, env.Value)
+		if err != nil {
+			return err
+		}
+		env.Value, env.ValueFrom = value, nil
+	}
+}
+
+func setEnv(c *corev1.Container, name, value string) {
+	for i := range c.Env {
+		if c.Env[i].Name == name {
+			c.Env[i].Value, c.Env[i].ValueFrom = value, nil
+			return
+		}
+	}
+	c.Env = append(c.Env, corev1.EnvVar{Name: name, Value: value})
+}
+
+// editResources asks for the cpu and memory requests and limits
+func (p *prompter) editResources(c *corev1.Container) error {
+	fields := []struct {
+		label string
+		list  *corev1.ResourceList
+		name  corev1.ResourceName
+	}{
+		{"CPU request", &c.Resources.Requests, corev1.ResourceCPU},
+		{"Memory request", &c.Resources.Requests, corev1.ResourceMemory},
+		{"CPU limit", &c.Resources.Limits, corev1.ResourceCPU},
+		{"Memory limit", &c.Resources.Limits, corev1.ResourceMemory},
+	}
+	fmt.Fprintln(p.out, "Enter a quantity, nothing to keep the current value, - to remove it")
+	for _, f := range fields {
+		current := ""
+		if q, ok := (*f.list)[f.name]; ok {
+			current = q.String()
+		}
+		for {
+			answer, err := p.askDefault(f.label, current)
+			if err != nil {
+				return err
+			}
+			if len(answer) == 0 {
+				delete(*f.list, f.name)
+				break
+			}
+			q, err := resource.ParseQuantity(answer)
+			if err != nil {
+				fmt.Fprintf(p.out, "Invalid quantity %q: %v\n", answer, err)
+				continue
+			}
+			if *f.list == nil {
+				*f.list = corev1.ResourceList{}
+			}
+			(*f.list)[f.name] = q
+			break
+		}
+	}
+	return nil
+}
+
+func removeContainer(spec *corev1.PodSpec, name string, init bool) {
+	containers := &spec.Containers
+	if init {
+		containers = &spec.InitContainers
+	}
+	for i := range *containers {
+		if (*containers)[i].Name == name {
+			*containers = append((*containers)[:i], (*containers)[i+1:]...)
+			return
+		}
+	}
+}
+
+func commandSummary(c *corev1.Container) string {
+	if len(c.Command) == 0 && len(c.Args) == 0 {
+		return "(image default)"
+	}
+	return strings.Join(append(append([]string{}, c.Command...), c.Args...), " ")
+}
+
+func probesSummary(c *corev1.Container) string {
+	var probes []string
+	if c.ReadinessProbe != nil {
+		probes = append(probes, "readiness")
+	}
+	if c.LivenessProbe != nil {
+		probes = append(probes, "liveness")
+	}
+	if c.StartupProbe != nil {
+		probes = append(probes, "startup")
+	}
+	if len(probes) == 0 {
+		return "none"
+	}
+	return strings.Join(probes, ", ")
+}
+
+func envSummary(env corev1.EnvVar) string {
+	switch {
+	case env.ValueFrom == nil:
+		return fmt.Sprintf("%s=%s", env.Name, env.Value)
+	case env.ValueFrom.SecretKeyRef != nil:
+		return fmt.Sprintf("%s from secret %s", env.Name, env.ValueFrom.SecretKeyRef.Name)
+	case env.ValueFrom.ConfigMapKeyRef != nil:
+		return fmt.Sprintf("%s from configmap %s", env.Name, env.ValueFrom.ConfigMapKeyRef.Name)
+	case env.ValueFrom.FieldRef != nil:
+		return fmt.Sprintf("%s from field %s", env.Name, env.ValueFrom.FieldRef.FieldPath)
+	}
+	return fmt.Sprintf("%s from a reference", env.Name)
+}
+
+func resourcesSummary(r corev1.ResourceRequirements) string {
+	quantity := func(list corev1.ResourceList, name corev1.ResourceName) string {
+		if q, ok := list[name]; ok {
+			return q.String()
+		}
+		return "-"
+	}
+	return fmt.Sprintf("cpu %s/%s, memory %s/%s",
+		quantity(r.Requests, corev1.ResourceCPU), quantity(r.Limits, corev1.ResourceCPU),
+		quantity(r.Requests, corev1.ResourceMemory), quantity(r.Limits, corev1.ResourceMemory))
+}
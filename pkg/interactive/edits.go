@@ -0,0 +1,99 @@
+package interactive
+
+import (
+	"fmt"
+
+	"dup/pkg/duplicate"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+)
+
+// edits returns the changes made to spec since original, as mutations to
+// record on the duplicate. Changes undone in the menus aren't recorded.
+func edits(original, spec *corev1.PodSpec) []duplicate.Mutation {
+	var descriptions []string
+	current := containerRefs(spec)
+	for _, o := range containerRefs(original) {
+		label := "container " + o.container.Name
+		if o.init {
+			label = "init " + label
+		}
+		c := findContainerRef(current, o)
+		if c == nil {
+			descriptions = append(descriptions, "removed "+label)
+			continue
+		}
+		for _, d := range containerEdits(o.container, c) {
+			descriptions = append(descriptions, fmt.Sprintf("%s: %s", label, d))
+		}
+	}
+	for _, v := range original.Volumes {
+		if findVolume(spec.Volumes, v.Name) < 0 {
+			descriptions = append(descriptions, fmt.Sprintf("removed volume %s and its mounts", v.Name))
+		}
+	}
+
+	mutations := make([]duplicate.Mutation, 0, len(descriptions))
+	for _, d := range descriptions {
+		mutations = append(mutations, duplicate.Mutation{Type: duplicate.MutationInteractive, Value: d})
+	}
+	return mutations
+}
+
+// containerEdits describes the fields of c the menus changed from original
+func containerEdits(original, c *corev1.Container) []string {
+	var ret []string
+	if c.Image != original.Image {
+		ret = append(ret, "image "+c.Image)
+	}
+	if commandSummary(c) != commandSummary(original) {
+		ret = append(ret, "command "+commandSummary(c))
+	}
+	probes := []struct {
+		name     string
+		original *corev1.Probe
+		probe    *corev1.Probe
+	}{
+		{"readiness", original.ReadinessProbe, c.ReadinessProbe},
+		{"liveness", original.LivenessProbe, c.LivenessProbe},
+		{"startup", original.StartupProbe, c.StartupProbe},
+	}
+	for _, p := range probes {
+		if p.original != nil && p.probe == nil {
+			ret = append(ret, fmt.Sprintf("removed %s probe", p.name))
+		}
+	}
+	for _, env := range original.Env {
+		if findEnv(c.Env, env.Name) == nil {
+			ret = append(ret, "removed env "+env.Name)
+		}
+	}
+	for _, env := range c.Env {
+		if previous := findEnv(original.Env, env.Name); previous == nil || !equality.Semantic.DeepEqual(*previous, env) {
+			ret = append(ret, "env "+envSummary(env))
+		}
+	}
+	if !equality.Semantic.DeepEqual(original.Resources, c.Resources) {
+		ret = append(ret, "resources "+resourcesSummary(c.Resources))
+	}
+	return ret
+}
+
+func findContainerRef(refs []containerRef, ref containerRef) *corev1.Container {
+	for _, r := range refs {
+		if r.init == ref.init && r.container.Name == ref.container.Name {
+			return r.container
+		}
+	}
+	return nil
+}
+
+func findEnv(env []corev1.EnvVar, name string) *corev1.EnvVar {
+	for i := range env {
+		if env[i].Name == name {
+			return &env[i]
+		}
+	}
+	return nil
+}
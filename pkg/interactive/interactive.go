@@ -0,0 +1,147 @@
+package interactive
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"dup/pkg/duplicate"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/cli-runtime/pkg/genericiooptions"
+)
+
+// ErrAborted is returned when the user quits without continuing
+var ErrAborted = errors.New("duplication aborted")
+
+// prompter reads answers line by line from a terminal
+type prompter struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+// ask prints question and returns the trimmed answer
+func (p *prompter) ask(question string) (string, error) {
+	fmt.Fprint(p.out, question)
+	line, err := p.in.ReadString('\n')
+	if err != nil && (err != io.EOF || len(line) == 0) {
+		if err == io.EOF {
+			return "", ErrAborted
+		}
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// askDefault asks for a value, an empty answer keeps current and "-" clears it
+func (p *prompter) askDefault(label, current string) (string, error) {
+	answer, err := p.ask(fmt.Sprintf("%s [%s]: ", label, current))
+	switch {
+	case err != nil:
+		return "", err
+	case len(answer) == 0:
+		return current, nil
+	case answer == "-":
+		return "", nil
+	}
+	return answer, nil
+}
+
+// Run lets the user change the pod specs of objects through menus, before
+// falling through to the editor. Objects without a pod spec are left as they
+// are, the others are replaced by their Go type. The changes are recorded on
+// the objects with duplicate.AddMutations.
+func Run(streams genericiooptions.IOStreams, objects []*runtime.Object) error {
+	p := &prompter{in: bufio.NewReader(streams.In), out: streams.Out}
+	for _, obj := range objects {
+		typed, spec, ok, err := duplicate.PodSpecOf(*obj)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		*obj = typed
+		original := spec.DeepCopy()
+		if err := p.objectMenu(typed, spec, original); err != nil {
+			return err
+		}
+		if mutations := edits(original, spec); len(mutations) > 0 {
+			if err := duplicate.AddMutations(typed, mutations...); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// objectMenu lists the containers and volumes of spec until the user
+// continues, original being spec before the menus
+func (p *prompter) objectMenu(obj runtime.Object, spec, original *corev1.PodSpec) error {
+	name := obj.GetObjectKind().GroupVersionKind().Kind
+	if accessor, err := meta.Accessor(obj); err == nil {
+		name = fmt.Sprintf("%s %q", name, accessor.GetName())
+	}
+	for {
+		fmt.Fprintf(p.out, "\n%s\n", name)
+		containers := containerRefs(spec)
+		for i, ref := range containers {
+			fmt.Fprintf(p.out, "  %d) %s\n", i+1, ref.summary())
+		}
+		fmt.Fprintf(p.out, "  v) volumes: %s\n", volumesSummary(spec))
+		answer, err := p.ask("Select a container to edit, v for volumes, c to continue, q to quit: ")
+		if err != nil {
+			return err
+		}
+		switch answer {
+		case "c", "":
+			return nil
+		case "q":
+			return ErrAborted
+		case "v":
+			if err := p.volumesMenu(spec, original); err != nil {
+				return err
+			}
+			continue
+		}
+		i, err := strconv.Atoi(answer)
+		if err != nil || i < 1 || i > len(containers) {
+			fmt.Fprintf(p.out, "Unknown choice %q\n", answer)
+			continue
+		}
+		if err := p.containerMenu(spec, containers[i-1]); err != nil {
+			return err
+		}
+	}
+}
+
+// containerRef locates a container in a pod spec, an init container or not
+type containerRef struct {
+	container *corev1.Container
+	init      bool
+}
+
+func containerRefs(spec *corev1.PodSpec) []containerRef {
+	var refs []containerRef
+	for i := range spec.InitContainers {
+		refs = append(refs, containerRef{container: &spec.InitContainers[i], init: true})
+	}
+	for i := range spec.Containers {
+		refs = append(refs, containerRef{container: &spec.Containers[i]})
+	}
+	return refs
+}
+
+func (r containerRef) summary() string {
+	c := r.container
+	kind := "container"
+	if r.init {
+		kind = "init container"
+	}
+	return fmt.Sprintf("%s %s: image %s, probes: %s, env: %d, resources: %s", kind, c.Name, c.Image, probesSummary(c), len(c.Env), resourcesSummary(c.Resources))
+}
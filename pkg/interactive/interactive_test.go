@@ -0,0 +1,193 @@
+package interactive
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"dup/pkg/duplicate"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/cli-runtime/pkg/genericiooptions"
+)
+
+func pod() *corev1.Pod {
+	probe := func(path string) *corev1.Probe {
+		return &corev1.Probe{ProbeHandler: corev1.ProbeHandler{HTTPGet: &corev1.HTTPGetAction{Path: path}}}
+	}
+	return &corev1.Pod{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"},
+		ObjectMeta: metav1.ObjectMeta{Name: "web"},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{
+					Name:           "app",
+					Image:          "app:v1",
+					ReadinessProbe: probe("/ready"),
+					LivenessProbe:  probe("/live"),
+					Env:            []corev1.EnvVar{{Name: "A", Value: "1"}, {Name: "B", Value: "2"}, {Name: "C", Value: "3"}},
+					VolumeMounts: []corev1.VolumeMount{
+						{Name: "config", MountPath: "/etc/app"},
+						{Name: "cache", MountPath: "/cache"},
+					},
+				},
+				{
+					Name:         "proxy",
+					Image:        "proxy:v1",
+					VolumeMounts: []corev1.VolumeMount{{Name: "config", MountPath: "/etc/proxy"}},
+				},
+			},
+			Volumes: []corev1.Volume{
+				{Name: "config", VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{LocalObjectReference: corev1.LocalObjectReference{Name: "config"}}}},
+				{Name: "cache", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+			},
+		},
+	}
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name string
+		// answers are the lines typed by the user
+		answers string
+		// edit makes the changes expected from the answers to the pod spec
+		edit      func(spec *corev1.PodSpec)
+		mutations []string
+		err       error
+	}{
+		{
+			name:    "no change",
+			answers: "c\n",
+		},
+		{
+			name:    "toggle a probe",
+			answers: "1\n3\n1\nb\nc\n",
+			edit: func(spec *corev1.PodSpec) {
+				spec.Containers[0].ReadinessProbe = nil
+			},
+			mutations: []string{"container app: removed readiness probe"},
+		},
+		{
+			name:    "toggle a probe back",
+			answers: "1\n3\n1\n3\n1\nb\nc\n",
+		},
+		{
+			name:    "remove all probes",
+			answers: "1\n3\na\nb\nc\n",
+			edit: func(spec *corev1.PodSpec) {
+				spec.Containers[0].ReadinessProbe, spec.Containers[0].LivenessProbe = nil, nil
+			},
+			mutations: []string{"container app: removed readiness probe", "container app: removed liveness probe"},
+		},
+		{
+			name:    "remove an env variable",
+			answers: "1\n4\n-2\nb\nb\nc\n",
+			edit: func(spec *corev1.PodSpec) {
+				spec.Containers[0].Env = []corev1.EnvVar{{Name: "A", Value: "1"}, {Name: "C", Value: "3"}}
+			},
+			mutations: []string{"container app: removed env B"},
+		},
+		{
+			name:    "out of range env removal",
+			answers: "1\n4\n-4\nb\nb\nc\n",
+		},
+		{
+			name:    "set env variables",
+			answers: "1\n4\nD=4\nA=0\nb\nb\nc\n",
+			edit: func(spec *corev1.PodSpec) {
+				spec.Containers[0].Env = []corev1.EnvVar{{Name: "A", Value: "0"}, {Name: "B", Value: "2"}, {Name: "C", Value: "3"}, {Name: "D", Value: "4"}}
+			},
+			mutations: []string{"container app: env A=0", "container app: env D=4"},
+		},
+		{
+			name:    "remove a volume",
+			answers: "v\n1\nb\nc\n",
+			edit: func(spec *corev1.PodSpec) {
+				spec.Volumes = spec.Volumes[1:]
+				spec.Containers[0].VolumeMounts = spec.Containers[0].VolumeMounts[1:]
+				spec.Containers[1].VolumeMounts = []corev1.VolumeMount{}
+			},
+			mutations: []string{"removed volume config and its mounts"},
+		},
+		{
+			name:    "restore a volume",
+			answers: "v\n1\n1\nb\nc\n",
+			edit: func(spec *corev1.PodSpec) {
+				// the volume and its mounts are added back at the end
+				spec.Volumes = append(spec.Volumes[1:], spec.Volumes[0])
+				mounts := spec.Containers[0].VolumeMounts
+				spec.Containers[0].VolumeMounts = []corev1.VolumeMount{mounts[1], mounts[0]}
+			},
+		},
+		{
+			name:    "remove a container",
+			answers: "2\nr\nc\n",
+			edit: func(spec *corev1.PodSpec) {
+				spec.Containers = spec.Containers[:1]
+			},
+			mutations: []string{"removed container proxy"},
+		},
+		{
+			name:    "quit",
+			answers: "1\n1\napp:v2\nb\nq\n",
+			err:     ErrAborted,
+		},
+		{
+			name:    "end of input",
+			answers: "1\n",
+			err:     ErrAborted,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			streams, in, _, _ := genericiooptions.NewTestIOStreams()
+			in.WriteString(tt.answers)
+			var obj runtime.Object = pod()
+			err := Run(streams, []*runtime.Object{&obj})
+			if err != tt.err {
+				t.Fatalf("expected the error %v, got %v", tt.err, err)
+			}
+			if err != nil {
+				return
+			}
+
+			want := pod()
+			if tt.edit != nil {
+				tt.edit(&want.Spec)
+			}
+			got := obj.(*corev1.Pod)
+			if !reflect.DeepEqual(got.Spec, want.Spec) {
+				t.Errorf("expected the spec\n%+v\ngot\n%+v", want.Spec, got.Spec)
+			}
+			mutations, err := duplicate.MutationsOf(got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var descriptions []string
+			for _, m := range mutations {
+				if m.Type != duplicate.MutationInteractive {
+					t.Errorf("unexpected mutation %+v", m)
+				}
+				descriptions = append(descriptions, m.Value)
+			}
+			if !reflect.DeepEqual(descriptions, tt.mutations) {
+				t.Errorf("expected the mutations %q, got %q", tt.mutations, descriptions)
+			}
+		})
+	}
+}
+
+func TestRunSkipsObjectsWithoutPodSpec(t *testing.T) {
+	streams, in, out, _ := genericiooptions.NewTestIOStreams()
+	in.WriteString("c\n")
+	var cm runtime.Object = &corev1.ConfigMap{TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"}}
+	var p runtime.Object = pod()
+	if err := Run(streams, []*runtime.Object{&cm, &p}); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(out.String(), "Select a container") != 1 {
+		t.Errorf("expected the menu of the pod only")
+	}
+}
@@ -0,0 +1,123 @@
+package interactive
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// volumesMenu toggles the volumes of spec, a removed volume takes its mounts
+// along and gets them back from original when added again.
+func (p *prompter) volumesMenu(spec, original *corev1.PodSpec) error {
+	for {
+		for i, v := range original.Volumes {
+			state := "removed"
+			if findVolume(spec.Volumes, v.Name) >= 0 {
+				state = fmt.Sprintf("mounts: %d", mountCount(spec, v.Name))
+			}
+			fmt.Fprintf(p.out, "  %d) %s (%s): %s\n", i+1, v.Name, volumeType(v), state)
+		}
+		answer, err := p.ask("Select a volume to toggle, b to go back: ")
+		if err != nil {
+			return err
+		}
+		if answer == "b" || answer == "" {
+			return nil
+		}
+		i, err := strconv.Atoi(answer)
+		if err != nil || i < 1 || i > len(original.Volumes) {
+			fmt.Fprintf(p.out, "Unknown choice %q\n", answer)
+			continue
+		}
+		volume := original.Volumes[i-1]
+		if j := findVolume(spec.Volumes, volume.Name); j >= 0 {
+			removeVolume(spec, j)
+		} else {
+			restoreVolume(spec, original, volume)
+		}
+	}
+}
+
+func removeVolume(spec *corev1.PodSpec, i int) {
+	name := spec.Volumes[i].Name
+	spec.Volumes = append(spec.Volumes[:i], spec.Volumes[i+1:]...)
+	for _, ref := range containerRefs(spec) {
+		mounts := ref.container.VolumeMounts[:0]
+		for _, m := range ref.container.VolumeMounts {
+			if m.Name != name {
+				mounts = append(mounts, m)
+			}
+		}
+		ref.container.VolumeMounts = mounts
+	}
+}
+
+func restoreVolume(spec, original *corev1.PodSpec, volume corev1.Volume) {
+	spec.Volumes = append(spec.Volumes, *volume.DeepCopy())
+	originals := containerRefs(original)
+	for _, ref := range containerRefs(spec) {
+		for _, o := range originals {
+			if o.init != ref.init || o.container.Name != ref.container.Name {
+				continue
+			}
+			for _, m := range o.container.VolumeMounts {
+				if m.Name == volume.Name {
+					ref.container.VolumeMounts = append(ref.container.VolumeMounts, m)
+				}
+			}
+		}
+	}
+}
+
+func findVolume(volumes []corev1.Volume, name string) int {
+	for i := range volumes {
+		if volumes[i].Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func mountCount(spec *corev1.PodSpec, name string) int {
+	count := 0
+	for _, ref := range containerRefs(spec) {
+		for _, m := range ref.container.VolumeMounts {
+			if m.Name == name {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+// volumeType returns the kind of source of v, such as persistentVolumeClaim
+func volumeType(v corev1.Volume) string {
+	switch s := v.VolumeSource; {
+	case s.PersistentVolumeClaim != nil:
+		return "pvc " + s.PersistentVolumeClaim.ClaimName
+	case s.ConfigMap != nil:
+		return "configmap " + s.ConfigMap.Name
+	case s.Secret != nil:
+		return "secret " + s.Secret.SecretName
+	case s.EmptyDir != nil:
+		return "emptyDir"
+	case s.HostPath != nil:
+		return "hostPath " + s.HostPath.Path
+	case s.Projected != nil:
+		return "projected"
+	}
+	return "other"
+}
+
+func volumesSummary(spec *corev1.PodSpec) string {
+	if len(spec.Volumes) == 0 {
+		return "none"
+	}
+	names := make([]string, 0, len(spec.Volumes))
+	for _, v := range spec.Volumes {
+		names = append(names, fmt.Sprintf("%s (%s)", v.Name, volumeType(v)))
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,217 @@
+package manage
+
+import (
+	"fmt"
+	"strings"
+
+	"dup/pkg/duplicate"
+	duputil "dup/pkg/util"
+
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/cli-runtime/pkg/genericiooptions"
+	"k8s.io/cli-runtime/pkg/resource"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+)
+
+// DeleteOptions contains all the options for running the delete cli command.
+type DeleteOptions struct {
+	Name    string
+	AllMine bool
+	Source  string
+
+	Cascade        string
+	DeletePVCs     bool
+	DryRunStrategy cmdutil.DryRunStrategy
+
+	Namespace         string
+	creator           string
+	propagationPolicy metav1.DeletionPropagation
+
+	f cmdutil.Factory
+	genericiooptions.IOStreams
+}
+
+// NewDeleteOptions returns an initialized DeleteOptions instance
+func NewDeleteOptions(ioStreams genericiooptions.IOStreams) *DeleteOptions {
+	return &DeleteOptions{
+		Cascade:   "background",
+		IOStreams: ioStreams,
+	}
+}
+
+// Complete completes all the required options
+func (o *DeleteOptions) Complete(f cmdutil.Factory, args []string, cmd *cobra.Command) error {
+	var err error
+
+	o.f = f
+	if len(args) > 0 {
+		o.Name = args[0]
+	}
+	o.Namespace, _, err = f.ToRawKubeConfigLoader().Namespace()
+	if err != nil {
+		return err
+	}
+	o.DryRunStrategy, err = cmdutil.GetDryRunStrategy(cmd)
+	if err != nil {
+		return err
+	}
+	switch o.Cascade {
+	case "background":
+		o.propagationPolicy = metav1.DeletePropagationBackground
+	case "foreground":
+		o.propagationPolicy = metav1.DeletePropagationForeground
+	case "orphan":
+		o.propagationPolicy = metav1.DeletePropagationOrphan
+	default:
+		return fmt.Errorf("invalid cascade value (%v). Must be \"background\", \"foreground\", or \"orphan\"", o.Cascade)
+	}
+	if o.AllMine {
+		o.creator = duputil.WhoAmI(f)
+		// an empty creator would match the duplicates of unknown creators
+		if len(o.creator) == 0 {
+			return fmt.Errorf("unable to determine the current user, --all-mine can't select your duplicates")
+		}
+	}
+	return nil
+}
+
+// Validate checks exactly one way of selecting duplicates was given
+func (o *DeleteOptions) Validate() error {
+	selectors := 0
+	for _, set := range []bool{len(o.Name) > 0, o.AllMine, len(o.Source) > 0} {
+		if set {
+			selectors++
+		}
+	}
+	if selectors != 1 {
+		return fmt.Errorf("exactly one of a duplicate name, --all-mine or --source must be given")
+	}
+	return nil
+}
+
+// Run deletes the selected duplicates together with everything created in
+// the same dup invocation
+func (o *DeleteOptions) Run() error {
+	infos, err := findDuplicates(o.f, o.Namespace, false, duplicate.ManagedByLabel+"="+duplicate.ManagedByValue)
+	if err != nil {
+		if len(infos) == 0 {
+			return err
+		}
+		fmt.Fprintf(o.ErrOut, "warning: %v\n", err)
+	}
+
+	selected, err := o.selectGroups(infos)
+	if err != nil {
+		return err
+	}
+	if len(selected) == 0 {
+		return fmt.Errorf("no matching duplicates found in namespace %q", o.Namespace)
+	}
+
+	// claims go last so that the pods using them are already terminating
+	var claims []*resource.Info
+	for _, info := range selected {
+		if info.Mapping.GroupVersionKind.Kind == "PersistentVolumeClaim" {
+			claims = append(claims, info)
+			continue
+		}
+		if err := o.delete(info); err != nil {
+			return err
+		}
+	}
+	if len(claims) == 0 {
+		return nil
+	}
+	// a dry run deletes nothing, there is nothing to confirm
+	if !o.DeletePVCs && o.DryRunStrategy == cmdutil.DryRunNone && !o.confirmClaims(claims) {
+		fmt.Fprintln(o.ErrOut, "Kept persistent volume claims, delete them with --delete-pvcs.")
+		return nil
+	}
+	for _, info := range claims {
+		if err := o.delete(info); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// selectGroups returns the infos matched by the options, expanded to
+// every other object sharing their invocation group.
+func (o *DeleteOptions) selectGroups(infos []*resource.Info) ([]*resource.Info, error) {
+	groups := map[string]bool{}
+	var selected []*resource.Info
+	for _, info := range infos {
+		accessor, err := meta.Accessor(info.Object)
+		if err != nil {
+			return nil, err
+		}
+		matched, err := o.matches(info, accessor)
+		if err != nil {
+			return nil, err
+		}
+		if !matched {
+			continue
+		}
+		if group, ok := accessor.GetLabels()[duplicate.GroupLabel]; ok {
+			groups[group] = true
+		} else {
+			selected = append(selected, info)
+		}
+	}
+	for _, info := range infos {
+		accessor, err := meta.Accessor(info.Object)
+		if err != nil {
+			return nil, err
+		}
+		if groups[accessor.GetLabels()[duplicate.GroupLabel]] {
+			selected = append(selected, info)
+		}
+	}
+	return selected, nil
+}
+
+func (o *DeleteOptions) matches(info *resource.Info, accessor metav1.Object) (bool, error) {
+	switch {
+	case len(o.Name) > 0:
+		return info.Name == o.Name, nil
+	case o.AllMine:
+		return accessor.GetAnnotations()[duplicate.CreatedByAnnotation] == o.creator, nil
+	}
+	source, ok, err := duplicate.SourceOf(accessor)
+	if err != nil || !ok {
+		return false, err
+	}
+	return matchesSource(source, o.Source), nil
+}
+
+func (o *DeleteOptions) delete(info *resource.Info) error {
+	if o.DryRunStrategy != cmdutil.DryRunClient {
+		_, err := resource.NewHelper(info.Client, info.Mapping).
+			DryRun(o.DryRunStrategy == cmdutil.DryRunServer).
+			DeleteWithOptions(info.Namespace, info.Name, &metav1.DeleteOptions{PropagationPolicy: &o.propagationPolicy})
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+	printOperation(o.Out, info, "deleted", o.DryRunStrategy)
+	return nil
+}
+
+func (o *DeleteOptions) confirmClaims(claims []*resource.Info) bool {
+	fmt.Fprintf(o.Out, "The following %d persistent volume claim(s) were provisioned by dup, their data will be lost:\n", len(claims))
+	for _, info := range claims {
+		fmt.Fprintf(o.Out, "%s/%s\n", kindString(info), info.Name)
+	}
+	fmt.Fprint(o.Out, "Do you want to delete them? (y/n): ")
+	var input string
+	if _, err := fmt.Fscan(o.In, &input); err != nil {
+		return false
+	}
+	return strings.EqualFold(input, "y")
+}
@@ -0,0 +1,130 @@
+package manage
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"dup/pkg/diff"
+
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericiooptions"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"k8s.io/kubectl/pkg/util/term"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	diffOutputJSONPatch = "json-patch"
+
+	colorAuto   = "auto"
+	colorAlways = "always"
+	colorNever  = "never"
+)
+
+// DiffOptions contains all the options for running the diff cli command.
+type DiffOptions struct {
+	Name   string
+	Output string
+	Color  string
+
+	Namespace string
+
+	f cmdutil.Factory
+	genericiooptions.IOStreams
+}
+
+// NewDiffOptions returns an initialized DiffOptions instance
+func NewDiffOptions(ioStreams genericiooptions.IOStreams) *DiffOptions {
+	return &DiffOptions{
+		Color:     colorAuto,
+		IOStreams: ioStreams,
+	}
+}
+
+// Complete completes all the required options
+func (o *DiffOptions) Complete(f cmdutil.Factory, args []string, cmd *cobra.Command) error {
+	var err error
+
+	o.f = f
+	o.Name = args[0]
+	o.Namespace, _, err = f.ToRawKubeConfigLoader().Namespace()
+	return err
+}
+
+// Validate validates the provided options
+func (o *DiffOptions) Validate() error {
+	switch o.Output {
+	case "", diffOutputJSONPatch:
+	default:
+		return fmt.Errorf("invalid output format %q, only %q is supported", o.Output, diffOutputJSONPatch)
+	}
+	return validateColor(o.Color)
+}
+
+// Run prints the changes between the source of o.Name and the duplicate
+func (o *DiffOptions) Run() error {
+	dupInfo, err := findDuplicate(o.f, o.Namespace, o.Name)
+	if err != nil {
+		return err
+	}
+	sourceInfo, source, err := fetchSource(o.f, dupInfo)
+	if err != nil {
+		return err
+	}
+	dupObj, err := asUnstructured(dupInfo)
+	if err != nil {
+		return err
+	}
+	sourceObj, err := asUnstructured(sourceInfo)
+	if err != nil {
+		return err
+	}
+	from, to := diff.Normalize(sourceObj), diff.Normalize(dupObj)
+
+	if o.Output == diffOutputJSONPatch {
+		patch := diff.JSONPatch(from.Object, to.Object)
+		if patch == nil {
+			patch = []diff.Operation{}
+		}
+		data, err := json.MarshalIndent(patch, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(o.Out, string(data))
+		return err
+	}
+
+	fromYAML, err := yaml.Marshal(from.Object)
+	if err != nil {
+		return err
+	}
+	toYAML, err := yaml.Marshal(to.Object)
+	if err != nil {
+		return err
+	}
+	changed, err := diff.Unified(o.Out,
+		fmt.Sprintf("%s/%s/%s (source)", source.Namespace, source.Kind, source.Name),
+		fmt.Sprintf("%s/%s/%s (duplicate)", dupInfo.Namespace, kindString(dupInfo), dupInfo.Name),
+		string(fromYAML), string(toYAML), useColor(o.Color, o.Out))
+	if err != nil {
+		return err
+	}
+	if !changed {
+		fmt.Fprintf(o.ErrOut, "%s/%s does not differ from %s\n", kindString(dupInfo), dupInfo.Name, source)
+	}
+	return nil
+}
+
+func validateColor(color string) error {
+	switch color {
+	case colorAuto, colorAlways, colorNever:
+		return nil
+	}
+	return fmt.Errorf("invalid --color %q, must be one of %s, %s or %s", color, colorAuto, colorAlways, colorNever)
+}
+
+// useColor returns whether diffs written to out are colored for the --color setting
+func useColor(color string, out io.Writer) bool {
+	return color == colorAlways || (color == colorAuto && term.IsTerminal(out))
+}
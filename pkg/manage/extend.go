@@ -0,0 +1,143 @@
+package manage
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"dup/pkg/duplicate"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/cli-runtime/pkg/genericiooptions"
+	"k8s.io/cli-runtime/pkg/resource"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+)
+
+// ExtendOptions contains all the options for running the extend cli command.
+type ExtendOptions struct {
+	Name     string
+	Duration time.Duration
+
+	Namespace string
+
+	f cmdutil.Factory
+	genericiooptions.IOStreams
+}
+
+// NewExtendOptions returns an initialized ExtendOptions instance
+func NewExtendOptions(ioStreams genericiooptions.IOStreams) *ExtendOptions {
+	return &ExtendOptions{
+		IOStreams: ioStreams,
+	}
+}
+
+// Complete completes all the required options
+func (o *ExtendOptions) Complete(f cmdutil.Factory, args []string, cmd *cobra.Command) error {
+	var err error
+
+	o.f = f
+	o.Name = args[0]
+	o.Duration, err = time.ParseDuration(args[1])
+	if err != nil {
+		return fmt.Errorf("invalid duration %q: %v", args[1], err)
+	}
+	if o.Duration <= 0 {
+		return fmt.Errorf("duration must be positive, got %s", o.Duration)
+	}
+	o.Namespace, _, err = f.ToRawKubeConfigLoader().Namespace()
+	return err
+}
+
+// Run pushes the expiry of every duplicate named o.Name out by o.Duration
+func (o *ExtendOptions) Run() error {
+	infos, err := findDuplicates(o.f, o.Namespace, false, duplicate.TTLLabel)
+	if err != nil && len(infos) == 0 {
+		return err
+	}
+
+	now := time.Now()
+	extended := 0
+	for _, info := range infos {
+		if info.Name != o.Name {
+			continue
+		}
+		expiresAt, err := o.extend(info, now)
+		if err != nil {
+			return err
+		}
+		printOperation(o.Out, info, fmt.Sprintf("extended until %s", expiresAt.Format(time.RFC3339)), cmdutil.DryRunNone)
+		extended++
+	}
+	if extended == 0 {
+		return fmt.Errorf("no duplicate named %q with a TTL found in namespace %q", o.Name, o.Namespace)
+	}
+	return nil
+}
+
+func (o *ExtendOptions) extend(info *resource.Info, now time.Time) (time.Time, error) {
+	accessor, err := meta.Accessor(info.Object)
+	if err != nil {
+		return time.Time{}, err
+	}
+	expiresAt, _, err := duplicate.ExpiresAt(accessor)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if expiresAt.Before(now) {
+		expiresAt = now
+	}
+	expiresAt = expiresAt.Add(o.Duration)
+
+	patch := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{
+				duplicate.ExpiresAtAnnotation: expiresAt.UTC().Format(time.RFC3339),
+			},
+		},
+	}
+	u, ok := info.Object.(*unstructured.Unstructured)
+	if !ok {
+		return time.Time{}, fmt.Errorf("unexpected object type %T", info.Object)
+	}
+	extraSeconds := int64(o.Duration.Seconds())
+	switch info.Mapping.GroupVersionKind.Kind {
+	case "Job":
+		if err := extendField(u, patch, extraSeconds, "spec", "ttlSecondsAfterFinished"); err != nil {
+			return time.Time{}, err
+		}
+	case "CronJob":
+		if err := extendField(u, patch, extraSeconds, "spec", "jobTemplate", "spec", "ttlSecondsAfterFinished"); err != nil {
+			return time.Time{}, err
+		}
+	case "Pod":
+		// the API server rejects raising activeDeadlineSeconds on a running pod
+		fmt.Fprintf(o.ErrOut, "warning: pod %q activeDeadlineSeconds cannot be increased, the pod will still be stopped at its original deadline\n", info.Name)
+	}
+
+	data, err := json.Marshal(patch)
+	if err != nil {
+		return time.Time{}, err
+	}
+	obj, err := resource.NewHelper(info.Client, info.Mapping).
+		Patch(info.Namespace, info.Name, types.MergePatchType, data, nil)
+	if err != nil {
+		return time.Time{}, err
+	}
+	info.Refresh(obj, true)
+	return expiresAt, nil
+}
+
+// extendField adds seconds to the integer field of u found at path, writing the result into patch
+func extendField(u *unstructured.Unstructured, patch map[string]interface{}, seconds int64, path ...string) error {
+	current, found, err := unstructured.NestedInt64(u.Object, path...)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return nil
+	}
+	return unstructured.SetNestedField(patch, current+seconds, path...)
+}
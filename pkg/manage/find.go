@@ -0,0 +1,102 @@
+package manage
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/discovery"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+)
+
+// duplicateResourceTypes returns every namespaced resource type that can be
+// listed and deleted. Duplicates may be of any kind, so all of them are searched.
+func duplicateResourceTypes(f cmdutil.Factory) ([]string, error) {
+	discoveryClient, err := f.ToDiscoveryClient()
+	if err != nil {
+		return nil, err
+	}
+	lists, err := discoveryClient.ServerPreferredNamespacedResources()
+	// partial discovery failures still return the groups that could be read
+	if err != nil && len(lists) == 0 {
+		return nil, err
+	}
+	lists = discovery.FilteredBy(discovery.SupportsAllVerbs{Verbs: []string{"list", "delete"}}, lists)
+
+	var types []string
+	for _, list := range lists {
+		gv, err := schema.ParseGroupVersion(list.GroupVersion)
+		if err != nil {
+			continue
+		}
+		for _, r := range list.APIResources {
+			// subresources and events are never duplicated
+			if strings.Contains(r.Name, "/") || r.Name == "events" {
+				continue
+			}
+			types = append(types, schema.GroupResource{Group: gv.Group, Resource: r.Name}.String())
+		}
+	}
+	return types, nil
+}
+
+// findDuplicates lists the top level duplicates matching selector. Objects
+// controlled by another object (e.g. the pods of a duplicated Deployment)
+// inherit dup metadata from their template and are left out. Errors listing
+// single resource types are returned alongside the objects that were found.
+func findDuplicates(f cmdutil.Factory, namespace string, allNamespaces bool, selector string) ([]*resource.Info, error) {
+	types, err := duplicateResourceTypes(f)
+	if err != nil {
+		return nil, err
+	}
+
+	result := f.NewBuilder().
+		Unstructured().
+		NamespaceParam(namespace).DefaultNamespace().AllNamespaces(allNamespaces).
+		LabelSelectorParam(selector).
+		ResourceTypes(types...).
+		ContinueOnError().
+		Flatten().
+		Do()
+
+	var infos []*resource.Info
+	visitErr := result.Visit(func(info *resource.Info, err error) error {
+		if err != nil {
+			return err
+		}
+		accessor, err := meta.Accessor(info.Object)
+		if err != nil {
+			return err
+		}
+		if metav1.GetControllerOf(accessor) != nil {
+			return nil
+		}
+		infos = append(infos, info)
+		return nil
+	})
+	return infos, visitErr
+}
+
+// kindString returns the lowercase kind of info qualified with its group, as kubectl prints it
+func kindString(info *resource.Info) string {
+	gvk := info.Mapping.GroupVersionKind
+	if len(gvk.Group) == 0 {
+		return strings.ToLower(gvk.Kind)
+	}
+	return fmt.Sprintf("%s.%s", strings.ToLower(gvk.Kind), gvk.Group)
+}
+
+// printOperation mirrors the name printer for objects that can no longer be printed
+func printOperation(out io.Writer, info *resource.Info, operation string, dryRunStrategy cmdutil.DryRunStrategy) {
+	switch dryRunStrategy {
+	case cmdutil.DryRunClient:
+		operation = fmt.Sprintf("%s (dry run)", operation)
+	case cmdutil.DryRunServer:
+		operation = fmt.Sprintf("%s (server dry run)", operation)
+	}
+	fmt.Fprintf(out, "%s %q %s\n", kindString(info), info.Name, operation)
+}
@@ -0,0 +1,109 @@
+package manage
+
+import (
+	"fmt"
+	"time"
+
+	"dup/pkg/duplicate"
+
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/cli-runtime/pkg/genericiooptions"
+	"k8s.io/cli-runtime/pkg/resource"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+)
+
+// GCOptions contains all the options for running the gc cli command.
+type GCOptions struct {
+	AllNamespaces  bool
+	DryRunStrategy cmdutil.DryRunStrategy
+
+	Namespace string
+
+	f cmdutil.Factory
+	genericiooptions.IOStreams
+}
+
+// NewGCOptions returns an initialized GCOptions instance
+func NewGCOptions(ioStreams genericiooptions.IOStreams) *GCOptions {
+	return &GCOptions{
+		AllNamespaces: true,
+		IOStreams:     ioStreams,
+	}
+}
+
+// Complete completes all the required options
+func (o *GCOptions) Complete(f cmdutil.Factory, args []string, cmd *cobra.Command) error {
+	var err error
+
+	o.f = f
+	o.Namespace, _, err = f.ToRawKubeConfigLoader().Namespace()
+	if err != nil {
+		return err
+	}
+	o.DryRunStrategy, err = cmdutil.GetDryRunStrategy(cmd)
+	return err
+}
+
+// Run deletes every duplicate whose TTL has passed
+func (o *GCOptions) Run() error {
+	infos, err := findDuplicates(o.f, o.Namespace, o.AllNamespaces, duplicate.TTLLabel)
+	if err != nil {
+		if len(infos) == 0 {
+			return err
+		}
+		fmt.Fprintf(o.ErrOut, "warning: %v\n", err)
+	}
+
+	now := time.Now()
+	deleted := 0
+	for _, info := range infos {
+		expired, err := isExpired(info, now)
+		if err != nil {
+			fmt.Fprintf(o.ErrOut, "warning: %v\n", err)
+			continue
+		}
+		if !expired {
+			continue
+		}
+		if err := o.deleteExpired(info); err != nil {
+			return err
+		}
+		deleted++
+	}
+	if deleted == 0 {
+		fmt.Fprintln(o.ErrOut, "No expired duplicates found.")
+	}
+	return nil
+}
+
+func (o *GCOptions) deleteExpired(info *resource.Info) error {
+	if o.DryRunStrategy != cmdutil.DryRunClient {
+		policy := metav1.DeletePropagationBackground
+		_, err := resource.NewHelper(info.Client, info.Mapping).
+			DryRun(o.DryRunStrategy == cmdutil.DryRunServer).
+			DeleteWithOptions(info.Namespace, info.Name, &metav1.DeleteOptions{PropagationPolicy: &policy})
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+	printOperation(o.Out, info, "deleted", o.DryRunStrategy)
+	return nil
+}
+
+func isExpired(info *resource.Info, now time.Time) (bool, error) {
+	accessor, err := meta.Accessor(info.Object)
+	if err != nil {
+		return false, err
+	}
+	expiresAt, ok, err := duplicate.ExpiresAt(accessor)
+	if err != nil || !ok {
+		return false, err
+	}
+	return !now.Before(expiresAt), nil
+}
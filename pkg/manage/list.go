@@ -0,0 +1,232 @@
+package manage
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"dup/pkg/duplicate"
+	duputil "dup/pkg/util"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/genericiooptions"
+	"k8s.io/cli-runtime/pkg/printers"
+	"k8s.io/cli-runtime/pkg/resource"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"k8s.io/kubectl/pkg/scheme"
+)
+
+// ListOptions contains all the options for running the list cli command.
+type ListOptions struct {
+	PrintFlags *genericclioptions.PrintFlags
+
+	AllNamespaces bool
+	Mine          bool
+	Source        string
+
+	Namespace string
+	creator   string
+
+	f cmdutil.Factory
+	genericiooptions.IOStreams
+}
+
+// NewListOptions returns an initialized ListOptions instance
+func NewListOptions(ioStreams genericiooptions.IOStreams) *ListOptions {
+	return &ListOptions{
+		PrintFlags: genericclioptions.NewPrintFlags("").WithTypeSetter(scheme.Scheme),
+		IOStreams:  ioStreams,
+	}
+}
+
+// Complete completes all the required options
+func (o *ListOptions) Complete(f cmdutil.Factory, args []string, cmd *cobra.Command) error {
+	var err error
+
+	o.f = f
+	o.Namespace, _, err = f.ToRawKubeConfigLoader().Namespace()
+	if err != nil {
+		return err
+	}
+	if o.Mine {
+		o.creator = duputil.WhoAmI(f)
+		// an empty creator would match the duplicates of unknown creators
+		if len(o.creator) == 0 {
+			return fmt.Errorf("unable to determine the current user, --mine can't select your duplicates")
+		}
+	}
+	return nil
+}
+
+// Run prints the duplicates matching the filters
+func (o *ListOptions) Run() error {
+	infos, err := findDuplicates(o.f, o.Namespace, o.AllNamespaces, duplicate.ManagedByLabel+"="+duplicate.ManagedByValue)
+	if err != nil {
+		if len(infos) == 0 {
+			return err
+		}
+		fmt.Fprintf(o.ErrOut, "warning: %v\n", err)
+	}
+
+	infos, err = o.filter(infos)
+	if err != nil {
+		return err
+	}
+	sort.SliceStable(infos, func(i, j int) bool {
+		if infos[i].Namespace != infos[j].Namespace {
+			return infos[i].Namespace < infos[j].Namespace
+		}
+		return infos[i].Name < infos[j].Name
+	})
+
+	output := *o.PrintFlags.OutputFormat
+	if len(output) > 0 && output != "wide" {
+		printer, err := o.PrintFlags.ToPrinter()
+		if err != nil {
+			return err
+		}
+		list := &unstructured.UnstructuredList{Object: map[string]interface{}{"apiVersion": "v1", "kind": "List"}}
+		for _, info := range infos {
+			list.Items = append(list.Items, *info.Object.(*unstructured.Unstructured))
+		}
+		return printer.PrintObj(list, o.Out)
+	}
+
+	if len(infos) == 0 {
+		if o.AllNamespaces {
+			fmt.Fprintln(o.ErrOut, "No duplicates found.")
+		} else {
+			fmt.Fprintf(o.ErrOut, "No duplicates found in %s namespace.\n", o.Namespace)
+		}
+		return nil
+	}
+	table, err := duplicatesTable(infos, time.Now())
+	if err != nil {
+		return err
+	}
+	printer := printers.NewTablePrinter(printers.PrintOptions{
+		WithNamespace: o.AllNamespaces,
+		Wide:          output == "wide",
+	})
+	return printer.PrintObj(table, o.Out)
+}
+
+func (o *ListOptions) filter(infos []*resource.Info) ([]*resource.Info, error) {
+	var ret []*resource.Info
+	for _, info := range infos {
+		accessor, err := meta.Accessor(info.Object)
+		if err != nil {
+			return nil, err
+		}
+		if o.Mine && accessor.GetAnnotations()[duplicate.CreatedByAnnotation] != o.creator {
+			continue
+		}
+		if len(o.Source) > 0 {
+			source, ok, err := duplicate.SourceOf(accessor)
+			if err != nil {
+				return nil, err
+			}
+			if !ok || !matchesSource(source, o.Source) {
+				continue
+			}
+		}
+		ret = append(ret, info)
+	}
+	return ret, nil
+}
+
+// matchesSource reports whether filter, either NAME or KIND/NAME, refers to source
+func matchesSource(source duplicate.Source, filter string) bool {
+	kind, name, found := strings.Cut(filter, "/")
+	if !found {
+		return source.Name == filter
+	}
+	return source.Name == name && strings.EqualFold(source.Kind, kind)
+}
+
+func duplicatesTable(infos []*resource.Info, now time.Time) (*metav1.Table, error) {
+	table := &metav1.Table{
+		ColumnDefinitions: []metav1.TableColumnDefinition{
+			{Name: "Name", Type: "string", Format: "name"},
+			{Name: "Source", Type: "string"},
+			{Name: "Creator", Type: "string"},
+			{Name: "Age", Type: "string"},
+			{Name: "TTL", Type: "string"},
+			{Name: "Status", Type: "string"},
+			{Name: "Mutations", Type: "string", Priority: 1},
+			{Name: "Group", Type: "string", Priority: 1},
+		},
+	}
+	for _, info := range infos {
+		u, ok := info.Object.(*unstructured.Unstructured)
+		if !ok {
+			return nil, fmt.Errorf("unexpected object type %T", info.Object)
+		}
+		source, ok, err := duplicate.SourceOf(u)
+		if err != nil {
+			return nil, err
+		}
+		sourceString := "<unknown>"
+		if ok {
+			sourceString = fmt.Sprintf("%s/%s", strings.ToLower(source.Kind), source.Name)
+		}
+		creator := u.GetAnnotations()[duplicate.CreatedByAnnotation]
+		if len(creator) == 0 {
+			creator = "<unknown>"
+		}
+		mutations, err := duplicate.MutationsOf(u)
+		if err != nil {
+			return nil, err
+		}
+		descriptions := make([]string, 0, len(mutations))
+		for _, m := range mutations {
+			descriptions = append(descriptions, m.String())
+		}
+		mutationsString := strings.Join(descriptions, ", ")
+		if len(mutationsString) == 0 {
+			mutationsString = "<none>"
+		}
+
+		table.Rows = append(table.Rows, metav1.TableRow{
+			Cells: []interface{}{
+				fmt.Sprintf("%s/%s", kindString(info), info.Name),
+				sourceString,
+				creator,
+				duration.HumanDuration(now.Sub(u.GetCreationTimestamp().Time)),
+				ttlRemaining(u, now),
+				duputil.WorkloadStatus(u),
+				mutationsString,
+				groupString(u),
+			},
+			Object: runtime.RawExtension{Object: u},
+		})
+	}
+	return table, nil
+}
+
+func groupString(u *unstructured.Unstructured) string {
+	if group, ok := u.GetLabels()[duplicate.GroupLabel]; ok {
+		return group
+	}
+	return "<none>"
+}
+
+func ttlRemaining(u *unstructured.Unstructured, now time.Time) string {
+	expiresAt, ok, err := duplicate.ExpiresAt(u)
+	switch {
+	case err != nil:
+		return "<invalid>"
+	case !ok:
+		return "<none>"
+	case !now.Before(expiresAt):
+		return "expired"
+	}
+	return duration.HumanDuration(expiresAt.Sub(now))
+}
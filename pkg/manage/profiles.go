@@ -0,0 +1,91 @@
+package manage
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"dup/pkg/config"
+
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericiooptions"
+	"k8s.io/cli-runtime/pkg/printers"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"sigs.k8s.io/yaml"
+)
+
+// ProfilesOptions contains all the options for running the profiles cli command.
+type ProfilesOptions struct {
+	Name string
+
+	config *config.Config
+	genericiooptions.IOStreams
+}
+
+// NewProfilesOptions returns an initialized ProfilesOptions instance
+func NewProfilesOptions(ioStreams genericiooptions.IOStreams) *ProfilesOptions {
+	return &ProfilesOptions{
+		IOStreams: ioStreams,
+	}
+}
+
+// Complete completes all the required options
+func (o *ProfilesOptions) Complete(f cmdutil.Factory, args []string, cmd *cobra.Command) error {
+	var err error
+
+	if len(args) > 0 {
+		o.Name = args[0]
+	}
+	o.config, err = config.Load()
+	return err
+}
+
+// Run lists the profiles, or shows the one named o.Name
+func (o *ProfilesOptions) Run() error {
+	if len(o.Name) > 0 {
+		return o.show()
+	}
+	if len(o.config.Profiles) == 0 {
+		path, err := config.Path()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(o.ErrOut, "No profiles found, define them in %s or %s.\n", path, config.RepoFile)
+		return nil
+	}
+	names := make([]string, 0, len(o.config.Profiles))
+	for name := range o.config.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	w := printers.GetNewTabWriter(o.Out)
+	fmt.Fprintln(w, "NAME\tDESCRIPTION\tFLAGS")
+	for _, name := range names {
+		p := o.config.Profiles[name]
+		fmt.Fprintf(w, "%s\t%s\t%s\n", name, p.Description, strings.Join(p.Flags(), " "))
+	}
+	return w.Flush()
+}
+
+func (o *ProfilesOptions) show() error {
+	p, err := o.config.Profile(o.Name)
+	if err != nil {
+		return err
+	}
+	w := printers.GetNewTabWriter(o.Out)
+	fmt.Fprintf(w, "Name:\t%s\n", p.Name)
+	fmt.Fprintf(w, "File:\t%s\n", p.File)
+	if len(p.Description) > 0 {
+		fmt.Fprintf(w, "Description:\t%s\n", p.Description)
+	}
+	fmt.Fprintf(w, "Flags:\t%s\n", strings.Join(p.Flags(), " "))
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	data, err := yaml.Marshal(p)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(o.Out, "\n%s", data)
+	return nil
+}
@@ -0,0 +1,346 @@
+package manage
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+
+	"dup/pkg/diff"
+	"dup/pkg/duplicate"
+	"dup/pkg/editor"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/cli-runtime/pkg/genericiooptions"
+	"k8s.io/cli-runtime/pkg/resource"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"k8s.io/kubectl/pkg/scheme"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	promoteStrategic  = "strategic"
+	promoteServerSide = "server-side"
+)
+
+// PromoteOptions contains all the options for running the promote cli command.
+type PromoteOptions struct {
+	Name           string
+	Type           string
+	Edit           bool
+	FieldManager   string
+	ForceConflicts bool
+	Color          string
+	DryRunStrategy cmdutil.DryRunStrategy
+
+	Namespace string
+
+	f cmdutil.Factory
+	genericiooptions.IOStreams
+}
+
+// NewPromoteOptions returns an initialized PromoteOptions instance
+func NewPromoteOptions(ioStreams genericiooptions.IOStreams) *PromoteOptions {
+	return &PromoteOptions{
+		Type:         promoteStrategic,
+		Edit:         true,
+		FieldManager: duplicate.ManagedByValue,
+		Color:        colorAuto,
+		IOStreams:    ioStreams,
+	}
+}
+
+// Complete completes all the required options
+func (o *PromoteOptions) Complete(f cmdutil.Factory, args []string, cmd *cobra.Command) error {
+	var err error
+
+	o.f = f
+	o.Name = args[0]
+	o.Namespace, _, err = f.ToRawKubeConfigLoader().Namespace()
+	if err != nil {
+		return err
+	}
+	o.DryRunStrategy, err = cmdutil.GetDryRunStrategy(cmd)
+	return err
+}
+
+// Validate validates the provided options
+func (o *PromoteOptions) Validate() error {
+	switch o.Type {
+	case promoteStrategic, promoteServerSide:
+	default:
+		return fmt.Errorf("invalid --type %q, must be %s or %s", o.Type, promoteStrategic, promoteServerSide)
+	}
+	if o.ForceConflicts && o.Type != promoteServerSide {
+		return fmt.Errorf("--force-conflicts only applies to --type=%s", promoteServerSide)
+	}
+	return validateColor(o.Color)
+}
+
+// Run shows the changes made on the duplicate o.Name, lets the user edit the
+// resulting patch and applies it to the source of the duplicate.
+func (o *PromoteOptions) Run() error {
+	dupInfo, err := findDuplicate(o.f, o.Namespace, o.Name)
+	if err != nil {
+		return err
+	}
+	sourceInfo, source, err := fetchSource(o.f, dupInfo)
+	if err != nil {
+		return err
+	}
+	dupObj, err := asUnstructured(dupInfo)
+	if err != nil {
+		return err
+	}
+	sourceObj, err := asUnstructured(sourceInfo)
+	if err != nil {
+		return err
+	}
+	// the changes dup made itself stay on the duplicate
+	reverted, err := duplicate.RevertMutations(dupObj, sourceObj)
+	if err != nil {
+		return err
+	}
+	from, to := diff.Normalize(sourceObj), diff.Normalize(reverted)
+
+	fromYAML, err := yaml.Marshal(from.Object)
+	if err != nil {
+		return err
+	}
+	toYAML, err := yaml.Marshal(to.Object)
+	if err != nil {
+		return err
+	}
+	changed, err := diff.Unified(o.Out,
+		fmt.Sprintf("%s/%s/%s (source)", source.Namespace, source.Kind, source.Name),
+		fmt.Sprintf("%s/%s/%s (duplicate)", dupInfo.Namespace, kindString(dupInfo), dupInfo.Name),
+		string(fromYAML), string(toYAML), useColor(o.Color, o.Out))
+	if err != nil {
+		return err
+	}
+	if !changed {
+		fmt.Fprintf(o.ErrOut, "Nothing to promote, %s/%s does not differ from its source.\n", kindString(dupInfo), dupInfo.Name)
+		return nil
+	}
+
+	patch, patchType, err := o.patchFor(sourceObj, from, to)
+	if err != nil {
+		return err
+	}
+	if o.Edit {
+		patch, err = o.editPatch(patch, patchType, source)
+		if err != nil {
+			return err
+		}
+		if patch == nil {
+			fmt.Fprintln(o.ErrOut, "Edit cancelled, no changes promoted.")
+			return nil
+		}
+	}
+
+	if o.DryRunStrategy != cmdutil.DryRunClient {
+		if err := o.apply(sourceInfo, patch, patchType); err != nil {
+			return err
+		}
+	}
+	printOperation(o.Out, sourceInfo, "promoted", o.DryRunStrategy)
+	return nil
+}
+
+// patchFor returns the patch turning from into to, both normalized, to be sent for sourceObj
+func (o *PromoteOptions) patchFor(sourceObj, from, to *unstructured.Unstructured) ([]byte, types.PatchType, error) {
+	if o.Type == promoteServerSide {
+		// the apply configuration only holds the changed fields, so that the
+		// field manager doesn't take ownership of the rest of the source
+		var schema strategicpatch.LookupPatchMeta
+		if versionedObject, err := scheme.Scheme.New(sourceObj.GroupVersionKind()); err == nil {
+			if meta, err := strategicpatch.NewPatchMetaFromStruct(versionedObject); err == nil {
+				schema = meta
+			}
+		}
+		changes, _, removed := changedFields(from.Object, to.Object, schema)
+		config := &unstructured.Unstructured{Object: map[string]interface{}{}}
+		if m, ok := changes.(map[string]interface{}); ok {
+			config.Object = m
+		}
+		config.SetAPIVersion(sourceObj.GetAPIVersion())
+		config.SetKind(sourceObj.GetKind())
+		config.SetName(sourceObj.GetName())
+		config.SetNamespace(sourceObj.GetNamespace())
+		if removed {
+			fmt.Fprintf(o.ErrOut, "Warning: fields removed from the duplicate are not removed from the source with --type=%s, use --type=%s to promote them.\n", promoteServerSide, promoteStrategic)
+		}
+		data, err := json.Marshal(config.Object)
+		return data, types.ApplyPatchType, err
+	}
+
+	fromJSON, err := json.Marshal(from.Object)
+	if err != nil {
+		return nil, "", err
+	}
+	toJSON, err := json.Marshal(to.Object)
+	if err != nil {
+		return nil, "", err
+	}
+	// custom resources have no patch strategy, they get a JSON merge patch
+	versionedObject, err := scheme.Scheme.New(sourceObj.GroupVersionKind())
+	if err != nil {
+		patch, err := jsonpatch.CreateMergePatch(fromJSON, toJSON)
+		return patch, types.MergePatchType, err
+	}
+	patch, err := strategicpatch.CreateTwoWayMergePatch(fromJSON, toJSON, versionedObject)
+	return patch, types.StrategicMergePatchType, err
+}
+
+// changedFields returns the parts of to that differ from from, whether they
+// differ at all, and whether from holds fields or list items to lacks. Lists
+// with a merge key in schema, such as containers or volume mounts, are
+// compared item by item and changed items are sent whole, so that they carry
+// every key the server identifies them with. Other lists, and every list
+// without a schema, are compared as a whole.
+func changedFields(from, to interface{}, schema strategicpatch.LookupPatchMeta) (interface{}, bool, bool) {
+	fromMap, fromIsMap := from.(map[string]interface{})
+	toMap, toIsMap := to.(map[string]interface{})
+	if fromIsMap && toIsMap {
+		changes := map[string]interface{}{}
+		removed := false
+		for key, value := range toMap {
+			fromValue, found := fromMap[key]
+			if !found {
+				changes[key] = value
+				continue
+			}
+			changed, differs, r := changedChild(key, fromValue, value, schema)
+			removed = removed || r
+			if differs {
+				changes[key] = changed
+			}
+		}
+		for key := range fromMap {
+			if _, found := toMap[key]; !found {
+				removed = true
+			}
+		}
+		return changes, len(changes) > 0, removed
+	}
+	if reflect.DeepEqual(from, to) {
+		return nil, false, false
+	}
+	fromList, fromIsList := from.([]interface{})
+	toList, toIsList := to.([]interface{})
+	return to, true, fromIsList && toIsList && len(toList) < len(fromList)
+}
+
+// changedChild returns the changes of the field key of an object described
+// by schema
+func changedChild(key string, from, to interface{}, schema strategicpatch.LookupPatchMeta) (interface{}, bool, bool) {
+	if schema == nil {
+		return changedFields(from, to, nil)
+	}
+	fromList, fromIsList := from.([]interface{})
+	toList, toIsList := to.([]interface{})
+	if !fromIsList || !toIsList {
+		child, _, err := schema.LookupPatchMetadataForStruct(key)
+		if err != nil {
+			child = nil
+		}
+		return changedFields(from, to, child)
+	}
+
+	_, meta, err := schema.LookupPatchMetadataForSlice(key)
+	mergeKey := meta.GetPatchMergeKey()
+	if err != nil || len(mergeKey) == 0 || !slices.Contains(meta.GetPatchStrategies(), "merge") ||
+		!keyedItems(fromList, mergeKey) || !keyedItems(toList, mergeKey) {
+		return changedFields(from, to, nil)
+	}
+	var changes []interface{}
+	removed := false
+	for _, item := range toList {
+		fromItem := findKeyed(fromList, mergeKey, item.(map[string]interface{})[mergeKey])
+		if fromItem == nil || !reflect.DeepEqual(fromItem, item) {
+			changes = append(changes, item)
+		}
+		if fromItem != nil {
+			_, _, r := changedFields(fromItem, item, nil)
+			removed = removed || r
+		}
+	}
+	for _, item := range fromList {
+		if findKeyed(toList, mergeKey, item.(map[string]interface{})[mergeKey]) == nil {
+			removed = true
+		}
+	}
+	return changes, len(changes) > 0, removed
+}
+
+// keyedItems returns whether every item of list is an object with a key field
+func keyedItems(list []interface{}, key string) bool {
+	for _, item := range list {
+		m, ok := item.(map[string]interface{})
+		if !ok || m[key] == nil {
+			return false
+		}
+	}
+	return true
+}
+
+func findKeyed(list []interface{}, key string, value interface{}) map[string]interface{} {
+	for _, item := range list {
+		if m := item.(map[string]interface{}); reflect.DeepEqual(m[key], value) {
+			return m
+		}
+	}
+	return nil
+}
+
+// editPatch opens patch in the user's editor, returning nil when the file was emptied
+func (o *PromoteOptions) editPatch(patch []byte, patchType types.PatchType, source duplicate.Source) ([]byte, error) {
+	patchYAML, err := yaml.JSONToYAML(patch)
+	if err != nil {
+		return nil, err
+	}
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, `# Please edit the %s patch below, it will be applied to %s.
+# Lines beginning with a '#' will be ignored, and an empty file will abort the promotion.
+#
+`, patchType, source)
+	buf.Write(patchYAML)
+
+	edit := editor.NewDefaultEditor(editor.EditorEnvs())
+	edited, file, err := edit.LaunchTempFile(fmt.Sprintf("%s-promote-", filepath.Base(os.Args[0])), ".yaml", buf)
+	if len(file) > 0 {
+		defer os.Remove(file)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var value interface{}
+	if err := yaml.Unmarshal(edited, &value); err != nil {
+		return nil, fmt.Errorf("the edited patch is not valid YAML: %v", err)
+	}
+	if value == nil {
+		return nil, nil
+	}
+	return json.Marshal(value)
+}
+
+func (o *PromoteOptions) apply(info *resource.Info, patch []byte, patchType types.PatchType) error {
+	options := &metav1.PatchOptions{}
+	if patchType == types.ApplyPatchType {
+		options.Force = &o.ForceConflicts
+	}
+	_, err := resource.NewHelper(info.Client, info.Mapping).
+		WithFieldManager(o.FieldManager).
+		DryRun(o.DryRunStrategy == cmdutil.DryRunServer).
+		Patch(info.Namespace, info.Name, patchType, patch, options)
+	return err
+}
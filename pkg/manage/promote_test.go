@@ -0,0 +1,97 @@
+package manage
+
+import (
+	"reflect"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+)
+
+func deploymentWith(container map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"spec": map[string]interface{}{
+			"replicas": int64(2),
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": []interface{}{
+						container,
+						map[string]interface{}{"name": "proxy", "image": "proxy:v1"},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestChangedFields(t *testing.T) {
+	app := func(image string, mounts, ports []interface{}) map[string]interface{} {
+		return map[string]interface{}{"name": "app", "image": image, "volumeMounts": mounts, "ports": ports}
+	}
+	mount := func(path, subPath string) interface{} {
+		return map[string]interface{}{"name": "config", "mountPath": path, "subPath": subPath}
+	}
+	port := func(name string, number int64) interface{} {
+		return map[string]interface{}{"name": name, "containerPort": number, "protocol": "TCP"}
+	}
+	mounts := []interface{}{mount("/etc/a", "a"), mount("/etc/b", "b")}
+	ports := []interface{}{port("http", 8080)}
+	from := deploymentWith(app("app:v1", mounts, ports))
+
+	tests := []struct {
+		name    string
+		to      map[string]interface{}
+		want    interface{}
+		removed bool
+	}{
+		{
+			name: "unchanged",
+			to:   deploymentWith(app("app:v1", mounts, ports)),
+			want: map[string]interface{}{},
+		},
+		{
+			name: "changed container is sent whole",
+			to:   deploymentWith(app("app:v2", mounts, ports)),
+			want: map[string]interface{}{"spec": map[string]interface{}{"template": map[string]interface{}{"spec": map[string]interface{}{
+				"containers": []interface{}{app("app:v2", mounts, ports)},
+			}}}},
+		},
+		{
+			name: "mounts of one volume are keyed by path",
+			to:   deploymentWith(app("app:v1", []interface{}{mount("/etc/a", "a"), mount("/etc/b", "c")}, ports)),
+			want: map[string]interface{}{"spec": map[string]interface{}{"template": map[string]interface{}{"spec": map[string]interface{}{
+				"containers": []interface{}{app("app:v1", []interface{}{mount("/etc/a", "a"), mount("/etc/b", "c")}, ports)},
+			}}}},
+		},
+		{
+			name: "renamed port keeps its keys",
+			to:   deploymentWith(app("app:v1", mounts, []interface{}{port("web", 8080)})),
+			want: map[string]interface{}{"spec": map[string]interface{}{"template": map[string]interface{}{"spec": map[string]interface{}{
+				"containers": []interface{}{app("app:v1", mounts, []interface{}{port("web", 8080)})},
+			}}}},
+		},
+		{
+			name:    "removed mount",
+			to:      deploymentWith(app("app:v1", []interface{}{mount("/etc/a", "a")}, ports)),
+			removed: true,
+			want: map[string]interface{}{"spec": map[string]interface{}{"template": map[string]interface{}{"spec": map[string]interface{}{
+				"containers": []interface{}{app("app:v1", []interface{}{mount("/etc/a", "a")}, ports)},
+			}}}},
+		},
+	}
+	schema, err := strategicpatch.NewPatchMetaFromStruct(&appsv1.Deployment{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, removed := changedFields(from, tt.to, schema)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %#v, got %#v", tt.want, got)
+			}
+			if removed != tt.removed {
+				t.Errorf("expected removed to be %v", tt.removed)
+			}
+		})
+	}
+}
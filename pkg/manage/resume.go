@@ -0,0 +1,58 @@
+package manage
+
+import (
+	"fmt"
+	"strings"
+
+	"dup/pkg/preserved"
+
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericiooptions"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+)
+
+// ResumeOptions contains all the options for running the resume cli command.
+type ResumeOptions struct {
+	File string
+	// Execute runs kubectl dup with args
+	Execute func(args []string) error
+
+	record *preserved.Record
+	// credentials are the credential flags given to resume, they are passed
+	// on since records leave them out
+	credentials []string
+	genericiooptions.IOStreams
+}
+
+// NewResumeOptions returns an initialized ResumeOptions instance
+func NewResumeOptions(ioStreams genericiooptions.IOStreams) *ResumeOptions {
+	return &ResumeOptions{
+		IOStreams: ioStreams,
+	}
+}
+
+// Complete completes all the required options
+func (o *ResumeOptions) Complete(f cmdutil.Factory, args []string, cmd *cobra.Command) error {
+	var err error
+
+	for _, name := range preserved.CredentialFlags {
+		if flag := cmd.Flags().Lookup(name); flag != nil && flag.Changed {
+			o.credentials = append(o.credentials, "--"+name+"="+flag.Value.String())
+		}
+	}
+	if len(args) > 0 {
+		o.File = args[0]
+		o.record, err = preserved.Find(o.File)
+		return err
+	}
+	o.record, err = preserved.Latest()
+	return err
+}
+
+// Run edits the preserved file again with the options of the command that
+// preserved it
+func (o *ResumeOptions) Run() error {
+	fmt.Fprintf(o.ErrOut, "Resuming %s (kubectl dup %s)\n", o.record.File, strings.Join(o.record.Args, " "))
+	args := append([]string{"--resume-file=" + o.record.File}, o.record.Args...)
+	return o.Execute(append(args, o.credentials...))
+}
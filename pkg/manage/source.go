@@ -0,0 +1,91 @@
+package manage
+
+import (
+	"fmt"
+	"strings"
+
+	"dup/pkg/duplicate"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/cli-runtime/pkg/resource"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+)
+
+// findDuplicate resolves arg, either NAME or TYPE/NAME, to a single duplicate in namespace
+func findDuplicate(f cmdutil.Factory, namespace, arg string) (*resource.Info, error) {
+	if strings.Contains(arg, "/") {
+		infos, err := f.NewBuilder().
+			Unstructured().
+			NamespaceParam(namespace).DefaultNamespace().
+			ResourceTypeOrNameArgs(false, arg).
+			SingleResourceType().
+			Flatten().
+			Do().
+			Infos()
+		if err != nil {
+			return nil, err
+		}
+		return infos[0], nil
+	}
+
+	infos, err := findDuplicates(f, namespace, false, duplicate.ManagedByLabel+"="+duplicate.ManagedByValue)
+	if err != nil && len(infos) == 0 {
+		return nil, err
+	}
+	var matches []*resource.Info
+	for _, info := range infos {
+		if info.Name == arg {
+			matches = append(matches, info)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("no duplicate named %q found in namespace %q", arg, namespace)
+	case 1:
+		return matches[0], nil
+	}
+	var kinds []string
+	for _, info := range matches {
+		kinds = append(kinds, fmt.Sprintf("%s/%s", kindString(info), info.Name))
+	}
+	return nil, fmt.Errorf("%q is ambiguous, use one of: %s", arg, strings.Join(kinds, ", "))
+}
+
+// fetchSource returns the current state of the object dup was cloned from
+func fetchSource(f cmdutil.Factory, dup *resource.Info) (*resource.Info, duplicate.Source, error) {
+	accessor, err := meta.Accessor(dup.Object)
+	if err != nil {
+		return nil, duplicate.Source{}, err
+	}
+	source, ok, err := duplicate.SourceOf(accessor)
+	if err != nil {
+		return nil, duplicate.Source{}, err
+	}
+	if !ok {
+		return nil, duplicate.Source{}, fmt.Errorf("%s %q has no %s annotation, it was not created by dup", kindString(dup), dup.Name, duplicate.SourceAnnotation)
+	}
+
+	gvk := schema.FromAPIVersionAndKind(source.APIVersion, source.Kind)
+	infos, err := f.NewBuilder().
+		Unstructured().
+		NamespaceParam(source.Namespace).
+		ResourceNames(fmt.Sprintf("%s.%s.%s", gvk.Kind, gvk.Version, gvk.Group), source.Name).
+		Flatten().
+		Do().
+		Infos()
+	if err != nil {
+		return nil, source, fmt.Errorf("unable to get source %s: %v", source, err)
+	}
+	return infos[0], source, nil
+}
+
+// asUnstructured returns the object of info, which the builders used here always decode as unstructured
+func asUnstructured(info *resource.Info) (*unstructured.Unstructured, error) {
+	u, ok := info.Object.(*unstructured.Unstructured)
+	if !ok {
+		return nil, fmt.Errorf("unexpected object type %T", info.Object)
+	}
+	return u, nil
+}
@@ -0,0 +1,286 @@
+package manage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"reflect"
+	"strings"
+
+	"dup/pkg/diff"
+	"dup/pkg/duplicate"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/cli-runtime/pkg/genericiooptions"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/tools/cache"
+	watchtools "k8s.io/client-go/tools/watch"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"sigs.k8s.io/yaml"
+)
+
+// SyncOptions contains all the options for running the sync cli command.
+type SyncOptions struct {
+	Name           string
+	Follow         bool
+	Overwrite      bool
+	Color          string
+	DryRunStrategy cmdutil.DryRunStrategy
+
+	Namespace string
+
+	f cmdutil.Factory
+	genericiooptions.IOStreams
+}
+
+// NewSyncOptions returns an initialized SyncOptions instance
+func NewSyncOptions(ioStreams genericiooptions.IOStreams) *SyncOptions {
+	return &SyncOptions{
+		Color:     colorAuto,
+		IOStreams: ioStreams,
+	}
+}
+
+// Complete completes all the required options
+func (o *SyncOptions) Complete(f cmdutil.Factory, args []string, cmd *cobra.Command) error {
+	var err error
+
+	o.f = f
+	o.Name = args[0]
+	o.Namespace, _, err = f.ToRawKubeConfigLoader().Namespace()
+	if err != nil {
+		return err
+	}
+	o.DryRunStrategy, err = cmdutil.GetDryRunStrategy(cmd)
+	return err
+}
+
+// Validate validates the provided options
+func (o *SyncOptions) Validate() error {
+	return validateColor(o.Color)
+}
+
+// Run reapplies the current pod template of the source of o.Name to the
+// duplicate, and keeps doing so on every change of the source with o.Follow.
+func (o *SyncOptions) Run() error {
+	dupInfo, err := findDuplicate(o.f, o.Namespace, o.Name)
+	if err != nil {
+		return err
+	}
+	sourceInfo, err := o.sync(dupInfo)
+	if err != nil || !o.Follow {
+		return err
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+	return o.follow(ctx, dupInfo, sourceInfo)
+}
+
+// follow watches the source and syncs the duplicate after each change of its spec
+func (o *SyncOptions) follow(ctx context.Context, dupInfo, sourceInfo *resource.Info) error {
+	helper := resource.NewHelper(sourceInfo.Client, sourceInfo.Mapping)
+	watcher, err := watchtools.NewRetryWatcher(sourceInfo.ResourceVersion, &cache.ListWatch{
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			return helper.WatchSingle(sourceInfo.Namespace, sourceInfo.Name, options.ResourceVersion)
+		},
+	})
+	if err != nil {
+		return err
+	}
+	defer watcher.Stop()
+
+	fmt.Fprintf(o.ErrOut, "Following %s/%s, press Ctrl+C to stop.\n", kindString(sourceInfo), sourceInfo.Name)
+	generation := generationOf(sourceInfo.Object)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-watcher.ResultChan():
+			if !ok {
+				return fmt.Errorf("watch of %s/%s closed", kindString(sourceInfo), sourceInfo.Name)
+			}
+			switch event.Type {
+			case watch.Deleted:
+				return fmt.Errorf("source %s/%s was deleted", kindString(sourceInfo), sourceInfo.Name)
+			case watch.Error:
+				return fmt.Errorf("watch of %s/%s failed: %v", kindString(sourceInfo), sourceInfo.Name, event.Object)
+			case watch.Modified:
+				// only spec changes bump the generation
+				if current := generationOf(event.Object); current != generation {
+					generation = current
+					if err := dupInfo.Get(); err != nil {
+						return err
+					}
+					if _, err := o.sync(dupInfo); err != nil {
+						fmt.Fprintf(o.ErrOut, "error: %v\n", err)
+					}
+				}
+			}
+		}
+	}
+}
+
+// sync reapplies the source template to dupInfo once and returns the source
+func (o *SyncOptions) sync(dupInfo *resource.Info) (*resource.Info, error) {
+	sourceInfo, _, err := fetchSource(o.f, dupInfo)
+	if err != nil {
+		return nil, err
+	}
+	dupObj, err := asUnstructured(dupInfo)
+	if err != nil {
+		return nil, err
+	}
+	sourceObj, err := asUnstructured(sourceInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	kind := dupObj.GetKind()
+	templatePath := duplicate.PodTemplatePath(kind)
+	// the pod template of a Job is immutable
+	if templatePath == nil || kind == "Job" {
+		return nil, fmt.Errorf("%s/%s has no pod template that can be synced", kindString(dupInfo), dupInfo.Name)
+	}
+	recorded, ok, err := duplicate.SourceTemplateOf(dupObj)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("%s/%s has no %s annotation, it was created by an older dup and can't be synced", kindString(dupInfo), dupInfo.Name, duplicate.SourceTemplateAnnotation)
+	}
+
+	baseObj := sourceObj.DeepCopy()
+	if err := unstructured.SetNestedMap(baseObj.Object, recorded, templatePath...); err != nil {
+		return nil, err
+	}
+	base := normalizedTemplate(baseObj, templatePath)
+	theirs := normalizedTemplate(sourceObj, templatePath)
+	if reflect.DeepEqual(base, theirs) {
+		fmt.Fprintf(o.ErrOut, "%s/%s is in sync with its source.\n", kindString(dupInfo), dupInfo.Name)
+		return sourceInfo, nil
+	}
+	// dup's own mutations are taken out so they don't count as edits of the user
+	reverted, err := duplicate.RevertMutations(dupObj, sourceObj)
+	if err != nil {
+		return nil, err
+	}
+	mine := normalizedTemplate(reverted, templatePath)
+
+	if conflicts := diff.Conflicts(base, theirs, mine); len(conflicts) > 0 && !o.Overwrite {
+		return nil, fmt.Errorf("the source changed fields edited on %s/%s, use --overwrite to take the source values:\n  %s",
+			kindString(dupInfo), dupInfo.Name, strings.Join(conflicts, "\n  "))
+	}
+	merged, err := mergeTemplates(base, theirs, mine)
+	if err != nil {
+		return nil, err
+	}
+
+	updated := dupObj.DeepCopy()
+	if err := unstructured.SetNestedMap(updated.Object, merged, templatePath...); err != nil {
+		return nil, err
+	}
+	restoreOwnedMetadata(updated, dupObj, templatePath)
+	if err := duplicate.ReapplyMutations(updated, dupObj); err != nil {
+		return nil, err
+	}
+	if err := duplicate.SetSourceTemplate(updated, sourceObj); err != nil {
+		return nil, err
+	}
+
+	if err := o.printChanges(dupInfo, dupObj, updated, templatePath); err != nil {
+		return nil, err
+	}
+	if o.DryRunStrategy != cmdutil.DryRunClient {
+		obj, err := resource.NewHelper(dupInfo.Client, dupInfo.Mapping).
+			DryRun(o.DryRunStrategy == cmdutil.DryRunServer).
+			Replace(dupInfo.Namespace, dupInfo.Name, true, updated)
+		if err != nil {
+			return nil, err
+		}
+		dupInfo.Refresh(obj, true)
+	}
+	printOperation(o.Out, dupInfo, "synced", o.DryRunStrategy)
+	return sourceInfo, nil
+}
+
+// printChanges prints the diff of the pod template of the duplicate before and after the sync
+func (o *SyncOptions) printChanges(info *resource.Info, before, after *unstructured.Unstructured, templatePath []string) error {
+	beforeYAML, err := yaml.Marshal(normalizedTemplate(before, templatePath))
+	if err != nil {
+		return err
+	}
+	afterYAML, err := yaml.Marshal(normalizedTemplate(after, templatePath))
+	if err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s/%s/%s %s", info.Namespace, kindString(info), info.Name, strings.Join(templatePath, "."))
+	_, err = diff.Unified(o.Out, name, name, string(beforeYAML), string(afterYAML), useColor(o.Color, o.Out))
+	return err
+}
+
+func normalizedTemplate(obj *unstructured.Unstructured, templatePath []string) map[string]interface{} {
+	template, _, _ := unstructured.NestedMap(diff.Normalize(obj).Object, templatePath...)
+	return template
+}
+
+// mergeTemplates applies the changes from base to theirs onto mine, theirs wins conflicts
+func mergeTemplates(base, theirs, mine map[string]interface{}) (map[string]interface{}, error) {
+	baseJSON, err := json.Marshal(base)
+	if err != nil {
+		return nil, err
+	}
+	theirsJSON, err := json.Marshal(theirs)
+	if err != nil {
+		return nil, err
+	}
+	mineJSON, err := json.Marshal(mine)
+	if err != nil {
+		return nil, err
+	}
+	patch, err := strategicpatch.CreateTwoWayMergePatch(baseJSON, theirsJSON, &corev1.PodTemplateSpec{})
+	if err != nil {
+		return nil, err
+	}
+	mergedJSON, err := strategicpatch.StrategicMergePatch(mineJSON, patch, &corev1.PodTemplateSpec{})
+	if err != nil {
+		return nil, err
+	}
+	var merged map[string]interface{}
+	return merged, json.Unmarshal(mergedJSON, &merged)
+}
+
+// restoreOwnedMetadata copies dup's labels and annotations of the template of
+// previous, dropped by normalization, back onto the template of updated.
+func restoreOwnedMetadata(updated, previous *unstructured.Unstructured, templatePath []string) {
+	for _, field := range []string{"labels", "annotations"} {
+		path := append(append([]string{}, templatePath...), "metadata", field)
+		values, _, _ := unstructured.NestedStringMap(previous.Object, path...)
+		merged, _, _ := unstructured.NestedStringMap(updated.Object, path...)
+		if merged == nil {
+			merged = map[string]string{}
+		}
+		for k, v := range values {
+			if duplicate.IsOwnedMetadata(k, v) {
+				merged[k] = v
+			}
+		}
+		if len(merged) > 0 {
+			unstructured.SetNestedStringMap(updated.Object, merged, path...)
+		}
+	}
+}
+
+func generationOf(obj interface{}) int64 {
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		return u.GetGeneration()
+	}
+	return 0
+}
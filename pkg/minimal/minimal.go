@@ -0,0 +1,193 @@
+// Package minimal hides the fields of objects that equal their API defaults,
+// and puts the defaults back once the objects were edited.
+package minimal
+
+import (
+	"reflect"
+	"strings"
+
+	"dup/pkg/duplicate"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// rule drops the field at path when it equals its default. A "*" element
+// of path stands for every item of a list.
+type rule struct {
+	path []string
+	// value returns the default of the field within parent
+	value func(parent map[string]interface{}) interface{}
+	// dropOnly rules are not restored, the server sets the field
+	dropOnly bool
+}
+
+func constant(v interface{}) func(map[string]interface{}) interface{} {
+	return func(map[string]interface{}) interface{} { return v }
+}
+
+// Minimize removes the server populated metadata, the status and the fields
+// equal to their defaults from obj.
+func Minimize(obj *unstructured.Unstructured) {
+	duplicate.RemoveServerMetadata(obj)
+	delete(obj.Object, "status")
+	for _, r := range rules(obj.GetKind()) {
+		walk(obj.Object, r.path, func(parent map[string]interface{}, key string) {
+			if value, ok := parent[key]; ok && reflect.DeepEqual(value, r.value(parent)) {
+				delete(parent, key)
+			}
+		})
+	}
+}
+
+// Restore sets the fields missing from obj to their defaults, so that obj is
+// equivalent to what it was before Minimize.
+func Restore(obj *unstructured.Unstructured) {
+	for _, r := range rules(obj.GetKind()) {
+		if r.dropOnly {
+			continue
+		}
+		walk(obj.Object, r.path, func(parent map[string]interface{}, key string) {
+			if _, ok := parent[key]; !ok {
+				parent[key] = runtime.DeepCopyJSONValue(r.value(parent))
+			}
+		})
+	}
+}
+
+// walk calls fn with the parent object and the key of every field matching
+// path, whether the field is set or not. Parents that don't exist are skipped.
+func walk(node interface{}, path []string, fn func(parent map[string]interface{}, key string)) {
+	if path[0] == "*" {
+		items, _ := node.([]interface{})
+		for _, item := range items {
+			walk(item, path[1:], fn)
+		}
+		return
+	}
+	m, ok := node.(map[string]interface{})
+	if !ok {
+		return
+	}
+	if len(path) == 1 {
+		fn(m, path[0])
+		return
+	}
+	if child, ok := m[path[0]]; ok {
+		walk(child, path[1:], fn)
+	}
+}
+
+// rules returns the defaults of objects of kind
+func rules(kind string) []rule {
+	var ret []rule
+	add := func(prefix []string, path string, value interface{}) {
+		ret = append(ret, rule{path: join(prefix, path), value: constant(value)})
+	}
+	for _, path := range templateMetadataPaths(kind) {
+		ret = append(ret, rule{path: join(path, "creationTimestamp"), value: constant(nil), dropOnly: true})
+	}
+
+	switch kind {
+	case "Deployment":
+		add(nil, "spec.progressDeadlineSeconds", int64(600))
+		add(nil, "spec.revisionHistoryLimit", int64(10))
+		add(nil, "spec.strategy", map[string]interface{}{
+			"type":          "RollingUpdate",
+			"rollingUpdate": map[string]interface{}{"maxSurge": "25%", "maxUnavailable": "25%"},
+		})
+	case "StatefulSet":
+		add(nil, "spec.podManagementPolicy", "OrderedReady")
+		add(nil, "spec.revisionHistoryLimit", int64(10))
+		add(nil, "spec.updateStrategy", map[string]interface{}{
+			"type":          "RollingUpdate",
+			"rollingUpdate": map[string]interface{}{"partition": int64(0)},
+		})
+	case "Job":
+		ret = append(ret, jobRules([]string{"spec"})...)
+	case "CronJob":
+		add(nil, "spec.concurrencyPolicy", "Allow")
+		add(nil, "spec.suspend", false)
+		add(nil, "spec.successfulJobsHistoryLimit", int64(3))
+		add(nil, "spec.failedJobsHistoryLimit", int64(1))
+		ret = append(ret, jobRules([]string{"spec", "jobTemplate", "spec"})...)
+	case "Pod":
+		add(nil, "spec.enableServiceLinks", true)
+	}
+
+	spec := duplicate.PodSpecPath(kind)
+	if spec == nil {
+		return ret
+	}
+	// jobs have to set their restart policy, Always is not valid for them
+	if kind != "Job" && kind != "CronJob" {
+		add(spec, "restartPolicy", "Always")
+	}
+	add(spec, "dnsPolicy", "ClusterFirst")
+	add(spec, "schedulerName", "default-scheduler")
+	add(spec, "securityContext", map[string]interface{}{})
+	add(spec, "terminationGracePeriodSeconds", int64(30))
+	for _, source := range []string{"configMap", "secret", "projected", "downwardAPI"} {
+		add(spec, "volumes.*."+source+".defaultMode", int64(420))
+	}
+	for _, containers := range []string{"containers", "initContainers"} {
+		c := join(spec, containers+".*")
+		add(c, "terminationMessagePath", "/dev/termination-log")
+		add(c, "terminationMessagePolicy", "File")
+		add(c, "resources", map[string]interface{}{})
+		add(c, "ports.*.protocol", "TCP")
+		add(c, "env.*.valueFrom.fieldRef.apiVersion", "v1")
+		ret = append(ret, rule{path: join(c, "imagePullPolicy"), value: defaultPullPolicy})
+		for _, probe := range []string{"readinessProbe", "livenessProbe", "startupProbe"} {
+			p := join(c, probe)
+			add(p, "timeoutSeconds", int64(1))
+			add(p, "periodSeconds", int64(10))
+			add(p, "successThreshold", int64(1))
+			add(p, "failureThreshold", int64(3))
+			add(p, "httpGet.scheme", "HTTP")
+		}
+	}
+	return ret
+}
+
+func jobRules(spec []string) []rule {
+	var ret []rule
+	for path, value := range map[string]interface{}{
+		"backoffLimit":   int64(6),
+		"completionMode": "NonIndexed",
+		"suspend":        false,
+		"parallelism":    int64(1),
+	} {
+		ret = append(ret, rule{path: join(spec, path), value: constant(value)})
+	}
+	return ret
+}
+
+// defaultPullPolicy returns the pull policy the server defaults for the image of container
+func defaultPullPolicy(container map[string]interface{}) interface{} {
+	image, _ := container["image"].(string)
+	if strings.Contains(image, "@") {
+		return "IfNotPresent"
+	}
+	name := image[strings.LastIndex(image, "/")+1:]
+	if _, tag, ok := strings.Cut(name, ":"); ok && tag != "latest" {
+		return "IfNotPresent"
+	}
+	return "Always"
+}
+
+// templateMetadataPaths returns the paths of the template metadata of kind
+func templateMetadataPaths(kind string) [][]string {
+	switch kind {
+	case "CronJob":
+		return [][]string{{"spec", "jobTemplate", "metadata"}, {"spec", "jobTemplate", "spec", "template", "metadata"}}
+	}
+	if path := duplicate.PodTemplatePath(kind); path != nil {
+		return [][]string{join(path, "metadata")}
+	}
+	return nil
+}
+
+func join(prefix []string, path string) []string {
+	return append(append([]string{}, prefix...), strings.Split(path, ".")...)
+}
@@ -0,0 +1,250 @@
+package minimal
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+type obj = map[string]interface{}
+type list = []interface{}
+
+// withDefaults returns bare with the fields of defaults it lacks, recursively
+// in objects and in the list items at the same index
+func withDefaults(bare, defaults interface{}) interface{} {
+	switch d := defaults.(type) {
+	case obj:
+		ret := runtime.DeepCopyJSONValue(bare).(obj)
+		for key, value := range d {
+			if existing, ok := ret[key]; ok {
+				ret[key] = withDefaults(existing, value)
+			} else {
+				ret[key] = runtime.DeepCopyJSONValue(value)
+			}
+		}
+		return ret
+	case list:
+		ret := runtime.DeepCopyJSONValue(bare).([]interface{})
+		for i := range ret {
+			if i < len(d) {
+				ret[i] = withDefaults(ret[i], d[i])
+			}
+		}
+		return ret
+	}
+	return bare
+}
+
+func container(image string) obj {
+	return obj{
+		"name":  "app",
+		"image": image,
+		"ports": list{obj{"containerPort": int64(8080)}},
+		"env": list{
+			obj{"name": "NODE", "valueFrom": obj{"fieldRef": obj{"fieldPath": "spec.nodeName"}}},
+		},
+		"readinessProbe": obj{"httpGet": obj{"path": "/", "port": int64(8080)}},
+	}
+}
+
+var containerDefaults = obj{
+	"terminationMessagePath":   "/dev/termination-log",
+	"terminationMessagePolicy": "File",
+	"resources":                obj{},
+	"imagePullPolicy":          "IfNotPresent",
+	"ports":                    list{obj{"protocol": "TCP"}},
+	"env":                      list{obj{"valueFrom": obj{"fieldRef": obj{"apiVersion": "v1"}}}},
+	"readinessProbe": obj{
+		"timeoutSeconds":   int64(1),
+		"periodSeconds":    int64(10),
+		"successThreshold": int64(1),
+		"failureThreshold": int64(3),
+		"httpGet":          obj{"scheme": "HTTP"},
+	},
+}
+
+func podSpec(restartPolicy string) obj {
+	spec := obj{
+		"containers": list{container("app:v1")},
+		"volumes":    list{obj{"name": "config", "configMap": obj{"name": "config"}}},
+	}
+	if len(restartPolicy) > 0 {
+		spec["restartPolicy"] = restartPolicy
+	}
+	return spec
+}
+
+var podSpecDefaults = obj{
+	"restartPolicy":                 "Always",
+	"dnsPolicy":                     "ClusterFirst",
+	"schedulerName":                 "default-scheduler",
+	"securityContext":               obj{},
+	"terminationGracePeriodSeconds": int64(30),
+	"containers":                    list{containerDefaults},
+	"volumes":                       list{obj{"configMap": obj{"defaultMode": int64(420)}}},
+}
+
+func template(spec obj) obj {
+	return obj{"metadata": obj{"labels": obj{"app": "web"}}, "spec": spec}
+}
+
+// templateDefaults are set by the server on the template at path
+func templateDefaults(path ...string) obj {
+	ret := obj{"metadata": obj{"creationTimestamp": nil}, "spec": podSpecDefaults}
+	for i := len(path) - 1; i >= 0; i-- {
+		ret = obj{path[i]: ret}
+	}
+	return ret
+}
+
+var jobDefaults = obj{
+	"backoffLimit":   int64(6),
+	"completionMode": "NonIndexed",
+	"suspend":        false,
+	"parallelism":    int64(1),
+}
+
+// serverFields are set by the server and never restored
+var serverFields = obj{
+	"metadata": obj{
+		"uid":               "6b1b5c3a-6a5c-4f3e-9a8e-2f0b0c1d2e3f",
+		"resourceVersion":   "1234",
+		"creationTimestamp": "2024-01-01T00:00:00Z",
+		"generation":        int64(2),
+		"managedFields":     list{obj{"manager": "kubectl", "operation": "Update"}},
+	},
+	"status": obj{"observedGeneration": int64(2)},
+}
+
+func TestMinimizeRestore(t *testing.T) {
+	tests := []struct {
+		kind string
+		// bare holds the fields set by the user
+		bare obj
+		// defaults holds the fields the server defaults
+		defaults obj
+	}{
+		{
+			kind: "Deployment",
+			bare: obj{"spec": obj{"replicas": int64(2), "template": template(podSpec(""))}},
+			defaults: withDefaults(templateDefaults("spec", "template"), obj{"spec": obj{
+				"progressDeadlineSeconds": int64(600),
+				"revisionHistoryLimit":    int64(10),
+				"strategy": obj{
+					"type":          "RollingUpdate",
+					"rollingUpdate": obj{"maxSurge": "25%", "maxUnavailable": "25%"},
+				},
+			}}).(obj),
+		},
+		{
+			kind: "StatefulSet",
+			bare: obj{"spec": obj{"serviceName": "web", "template": template(podSpec(""))}},
+			defaults: withDefaults(templateDefaults("spec", "template"), obj{"spec": obj{
+				"podManagementPolicy":  "OrderedReady",
+				"revisionHistoryLimit": int64(10),
+				"updateStrategy": obj{
+					"type":          "RollingUpdate",
+					"rollingUpdate": obj{"partition": int64(0)},
+				},
+			}}).(obj),
+		},
+		{
+			kind:     "Job",
+			bare:     obj{"spec": obj{"template": template(podSpec("Never"))}},
+			defaults: withDefaults(templateDefaults("spec", "template"), obj{"spec": jobDefaults}).(obj),
+		},
+		{
+			kind: "CronJob",
+			bare: obj{"spec": obj{
+				"schedule": "*/5 * * * *",
+				"jobTemplate": obj{
+					"metadata": obj{"labels": obj{"app": "web"}},
+					"spec":     obj{"template": template(podSpec("OnFailure"))},
+				},
+			}},
+			defaults: withDefaults(templateDefaults("spec", "jobTemplate", "spec", "template"), obj{"spec": obj{
+				"concurrencyPolicy":          "Allow",
+				"suspend":                    false,
+				"successfulJobsHistoryLimit": int64(3),
+				"failedJobsHistoryLimit":     int64(1),
+				"jobTemplate": obj{
+					"metadata": obj{"creationTimestamp": nil},
+					"spec":     jobDefaults,
+				},
+			}}).(obj),
+		},
+		{
+			kind:     "Pod",
+			bare:     obj{"spec": podSpec("")},
+			defaults: obj{"spec": withDefaults(obj{"enableServiceLinks": true}, podSpecDefaults)},
+		},
+		{
+			kind: "ConfigMap",
+			bare: obj{"data": obj{"mode": "debug"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.kind, func(t *testing.T) {
+			bare := withDefaults(tt.bare, obj{"kind": tt.kind, "metadata": obj{"name": "web"}}).(obj)
+			defaulted := withDefaults(withDefaults(bare, tt.defaults), serverFields).(obj)
+
+			minimized := &unstructured.Unstructured{Object: runtime.DeepCopyJSON(defaulted)}
+			Minimize(minimized)
+			if !reflect.DeepEqual(minimized.Object, bare) {
+				t.Errorf("Minimize: expected\n%#v\ngot\n%#v", bare, minimized.Object)
+			}
+
+			// the server fields and the template creation timestamps are not restored
+			want := withDefaults(bare, tt.defaults).(obj)
+			for _, r := range rules(tt.kind) {
+				if r.dropOnly {
+					walk(want, r.path, func(parent map[string]interface{}, key string) { delete(parent, key) })
+				}
+			}
+			Restore(minimized)
+			if !reflect.DeepEqual(minimized.Object, want) {
+				t.Errorf("Restore: expected\n%#v\ngot\n%#v", want, minimized.Object)
+			}
+		})
+	}
+}
+
+func TestImagePullPolicy(t *testing.T) {
+	tests := []struct {
+		image  string
+		policy string
+		// kept is whether Minimize keeps the policy as it isn't the default
+		kept bool
+	}{
+		{image: "app", policy: "Always"},
+		{image: "app:latest", policy: "Always"},
+		{image: "app:v1", policy: "IfNotPresent"},
+		{image: "registry:5000/app", policy: "Always"},
+		{image: "registry:5000/app:v1", policy: "IfNotPresent"},
+		{image: "app@sha256:0123456789abcdef", policy: "IfNotPresent"},
+		{image: "app:v1", policy: "Always", kept: true},
+		{image: "app", policy: "IfNotPresent", kept: true},
+		{image: "app:v1", policy: "Never", kept: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.image+" "+tt.policy, func(t *testing.T) {
+			c := obj{"name": "app", "image": tt.image, "imagePullPolicy": tt.policy}
+			pod := &unstructured.Unstructured{Object: obj{
+				"kind":     "Pod",
+				"metadata": obj{"name": "web"},
+				"spec":     obj{"containers": list{c}},
+			}}
+			Minimize(pod)
+			_, kept := c["imagePullPolicy"]
+			if kept != tt.kept {
+				t.Errorf("Minimize: expected the policy kept to be %v", tt.kept)
+			}
+			Restore(pod)
+			if c["imagePullPolicy"] != tt.policy {
+				t.Errorf("Restore: expected %q, got %v", tt.policy, c["imagePullPolicy"])
+			}
+		})
+	}
+}
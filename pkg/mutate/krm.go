@@ -0,0 +1,179 @@
+package mutate
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/shlex"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	utiljson "k8s.io/apimachinery/pkg/util/json"
+	"sigs.k8s.io/yaml"
+)
+
+// Function is a KRM function run as a local executable, reading a
+// ResourceList on stdin and writing the changed one on stdout.
+type Function struct {
+	Exec string   `json:"exec"`
+	Args []string `json:"args,omitempty"`
+	// Config is passed to the function as the functionConfig of the ResourceList
+	Config map[string]interface{} `json:"config,omitempty"`
+}
+
+// ParseFunction parses a --fn command line, the executable followed by its arguments
+func ParseFunction(command string) (Function, error) {
+	words, err := shlex.Split(command)
+	if err != nil {
+		return Function{}, fmt.Errorf("invalid --fn %q: %v", command, err)
+	}
+	if len(words) == 0 {
+		return Function{}, fmt.Errorf("invalid --fn %q: no executable", command)
+	}
+	return Function{Exec: words[0], Args: words[1:]}, nil
+}
+
+func (fn Function) String() string {
+	return strings.Join(append([]string{filepath.Base(fn.Exec)}, fn.Args...), " ")
+}
+
+// Report describes the outcome of a step of the pipeline worth showing to the
+// user, Failed when the step did not change the objects.
+type Report struct {
+	Head    string
+	Details []string
+	Failed  bool
+}
+
+// resourceList is the input and output of KRM functions
+type resourceList struct {
+	APIVersion     string                   `json:"apiVersion"`
+	Kind           string                   `json:"kind"`
+	Items          []map[string]interface{} `json:"items"`
+	FunctionConfig map[string]interface{}   `json:"functionConfig,omitempty"`
+	Results        []functionResult         `json:"results,omitempty"`
+}
+
+type functionResult struct {
+	Message     string `json:"message"`
+	Severity    string `json:"severity,omitempty"`
+	ResourceRef *struct {
+		Kind      string `json:"kind,omitempty"`
+		Name      string `json:"name,omitempty"`
+		Namespace string `json:"namespace,omitempty"`
+	} `json:"resourceRef,omitempty"`
+	Field *struct {
+		Path string `json:"path,omitempty"`
+	} `json:"field,omitempty"`
+}
+
+func (r functionResult) String() string {
+	var b strings.Builder
+	if len(r.Severity) > 0 {
+		fmt.Fprintf(&b, "[%s] ", r.Severity)
+	}
+	if r.ResourceRef != nil {
+		fmt.Fprintf(&b, "%s %q", r.ResourceRef.Kind, r.ResourceRef.Name)
+		if r.Field != nil && len(r.Field.Path) > 0 {
+			fmt.Fprintf(&b, " %s", r.Field.Path)
+		}
+		b.WriteString(": ")
+	}
+	b.WriteString(r.Message)
+	return b.String()
+}
+
+// runFunction pipes objects through fn. When the function fails, or returns
+// a result with the error severity, objects are returned unchanged along with
+// a failed report.
+func runFunction(fn Function, objects []*runtime.Object) ([]*runtime.Object, *Report, error) {
+	input := resourceList{APIVersion: "config.kubernetes.io/v1", Kind: "ResourceList", FunctionConfig: fn.Config}
+	for _, obj := range objects {
+		content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(*obj)
+		if err != nil {
+			return nil, nil, err
+		}
+		input.Items = append(input.Items, content)
+	}
+	data, err := yaml.Marshal(input)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(fn.Exec, fn.Args...)
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	runErr := cmd.Run()
+
+	var output resourceList
+	parseErr := decodeResourceList(stdout.Bytes(), &output)
+	report := &Report{Head: fmt.Sprintf("KRM function %s", fn)}
+	for _, result := range output.Results {
+		report.Details = append(report.Details, result.String())
+		if result.Severity == "error" {
+			report.Failed = true
+		}
+	}
+	if runErr != nil || parseErr != nil {
+		report.Failed = true
+		if runErr != nil {
+			report.Details = append(report.Details, runErr.Error())
+		} else {
+			report.Details = append(report.Details, fmt.Sprintf("invalid output: %v", parseErr))
+		}
+		if msg := strings.TrimSpace(stderr.String()); len(msg) > 0 {
+			report.Details = append(report.Details, strings.Split(msg, "\n")...)
+		}
+	}
+	if report.Failed {
+		report.Head += " failed, the objects are left as they were before it"
+		return objects, report, nil
+	}
+	if len(report.Details) == 0 {
+		report = nil
+	} else {
+		report.Head += " reported"
+	}
+
+	ret := make([]*runtime.Object, 0, len(output.Items))
+	for _, item := range output.Items {
+		var obj runtime.Object = &unstructured.Unstructured{Object: item}
+		ret = append(ret, &obj)
+	}
+	return ret, report, nil
+}
+
+// decodeResourceList parses the YAML or JSON output of a function, keeping
+// integers as int64 like the rest of unstructured content
+func decodeResourceList(data []byte, list *resourceList) error {
+	jsonData, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(jsonData, list); err != nil {
+		return err
+	}
+	if list.Kind != "ResourceList" {
+		return fmt.Errorf("expected a ResourceList, got kind %q", list.Kind)
+	}
+	// decoded again as unstructured content, integers would be float64 otherwise
+	var content map[string]interface{}
+	if err := utiljson.Unmarshal(jsonData, &content); err != nil {
+		return err
+	}
+	items, _ := content["items"].([]interface{})
+	list.Items = make([]map[string]interface{}, 0, len(items))
+	for _, item := range items {
+		obj, ok := item.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("items must be objects")
+		}
+		list.Items = append(list.Items, obj)
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package mutate
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestRunFunction(t *testing.T) {
+	settings := configMap().Object
+	debug := configMap().Object
+	debug["data"] = map[string]interface{}{"mode": "debug"}
+	generated := map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]interface{}{"name": "web"},
+		"spec":       map[string]interface{}{"replicas": int64(2)},
+	}
+
+	tests := []struct {
+		mode string
+		want []map[string]interface{}
+		// report is nil when the function reports nothing
+		report *Report
+		// details are the first lines of the details of a failed report
+		details []string
+	}{
+		{
+			mode: "debug",
+			want: []map[string]interface{}{debug},
+		},
+		{
+			mode: "json",
+			want: []map[string]interface{}{generated},
+		},
+		{
+			mode: "warning",
+			want: []map[string]interface{}{settings},
+			report: &Report{
+				Head:    "KRM function fn.sh warning reported",
+				Details: []string{`[warning] ConfigMap "settings": mode is prod`},
+			},
+		},
+		{
+			mode: "error",
+			want: []map[string]interface{}{settings},
+			report: &Report{
+				Head:    "KRM function fn.sh error failed, the objects are left as they were before it",
+				Details: []string{`[error] ConfigMap "settings": mode is prod`},
+				Failed:  true,
+			},
+		},
+		{
+			mode: "fail",
+			want: []map[string]interface{}{settings},
+			report: &Report{
+				Head:    "KRM function fn.sh fail failed, the objects are left as they were before it",
+				Details: []string{"exit status 1", "cannot reach the registry"},
+				Failed:  true,
+			},
+		},
+		{
+			mode:    "invalid",
+			want:    []map[string]interface{}{settings},
+			details: []string{"invalid output: "},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			var obj runtime.Object = configMap()
+			objects := []*runtime.Object{&obj}
+			output, report, err := runFunction(Function{Exec: "testdata/fn.sh", Args: []string{tt.mode}}, objects)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make([]map[string]interface{}, 0, len(output))
+			for _, o := range output {
+				got = append(got, (*o).(*unstructured.Unstructured).Object)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %#v, got %#v", tt.want, got)
+			}
+			if len(tt.details) > 0 {
+				if report == nil || !report.Failed || len(report.Details) != len(tt.details) || !strings.HasPrefix(report.Details[0], tt.details[0]) {
+					t.Errorf("expected a failed report with the details %q, got %+v", tt.details, report)
+				}
+				return
+			}
+			if !reflect.DeepEqual(report, tt.report) {
+				t.Errorf("expected the report %+v, got %+v", tt.report, report)
+			}
+		})
+	}
+}
+
+func TestDecodeResourceList(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want []map[string]interface{}
+		err  bool
+	}{
+		{
+			name: "yaml",
+			data: "apiVersion: config.kubernetes.io/v1\nkind: ResourceList\nitems:\n- kind: ConfigMap\n  data:\n    ratio: '0.5'\n  spec:\n    replicas: 2\n",
+			want: []map[string]interface{}{{"kind": "ConfigMap", "data": map[string]interface{}{"ratio": "0.5"}, "spec": map[string]interface{}{"replicas": int64(2)}}},
+		},
+		{
+			name: "json",
+			data: `{"kind": "ResourceList", "items": [{"kind": "ConfigMap", "spec": {"replicas": 2, "ratio": 0.5}}]}`,
+			want: []map[string]interface{}{{"kind": "ConfigMap", "spec": map[string]interface{}{"replicas": int64(2), "ratio": 0.5}}},
+		},
+		{
+			name: "no items",
+			data: "kind: ResourceList\n",
+			want: []map[string]interface{}{},
+		},
+		{
+			name: "another kind",
+			data: "kind: List\nitems: []\n",
+			err:  true,
+		},
+		{
+			name: "item that is not an object",
+			data: "kind: ResourceList\nitems:\n- web\n",
+			err:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var list resourceList
+			err := decodeResourceList([]byte(tt.data), &list)
+			if tt.err {
+				if err == nil {
+					t.Errorf("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(list.Items, tt.want) {
+				t.Errorf("expected %#v, got %#v", tt.want, list.Items)
+			}
+		})
+	}
+}
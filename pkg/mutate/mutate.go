@@ -0,0 +1,292 @@
+package mutate
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+
+	"dup/pkg/duplicate"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/cli-runtime/pkg/genericiooptions"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/kubectl/pkg/scheme"
+)
+
+const (
+	PatchStrategic = "strategic"
+	PatchMerge     = "merge"
+	PatchJSON      = "json"
+)
+
+// Options contains the changes applied to every duplicate before it is edited.
+type Options struct {
+	Set        []string
+	Patches    []string
+	PatchFiles []string
+	PatchType  string
+	// Transforms are Starlark scripts defining transform(obj, ctx)
+	Transforms []string
+	// Fn holds the command lines of KRM functions given as flags
+	Fn []string
+	// Functions are KRM functions run before the ones of Fn
+	Functions []Function
+
+	// ErrOut receives what the transforms print
+	genericiooptions.IOStreams
+}
+
+// NewOptions returns an initialized Options instance
+func NewOptions(ioStreams genericiooptions.IOStreams) *Options {
+	return &Options{
+		PatchType: PatchStrategic,
+		IOStreams: ioStreams,
+	}
+}
+
+// Enabled returns whether any change was requested
+func (o *Options) Enabled() bool {
+	return len(o.Set) > 0 || len(o.Patches) > 0 || len(o.PatchFiles) > 0 || len(o.Transforms) > 0 ||
+		len(o.Fn) > 0 || len(o.Functions) > 0
+}
+
+// Validate checks the patch type and the syntax of the setters
+func (o *Options) Validate() error {
+	switch o.PatchType {
+	case PatchStrategic, PatchMerge, PatchJSON:
+	default:
+		return fmt.Errorf("invalid --patch-type %q, must be one of %s, %s or %s", o.PatchType, PatchStrategic, PatchMerge, PatchJSON)
+	}
+	for _, expr := range o.Set {
+		if _, err := parseSetter(expr); err != nil {
+			return err
+		}
+	}
+	for _, command := range o.Fn {
+		if _, err := ParseFunction(command); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Apply returns the objects, cloned from the source at the same index with
+// pod, changed by the patch files first, then the inline patches, the
+// setters, the transforms and finally the KRM functions, each in the order
+// they were given. Every change is recorded on the objects it changed with
+// duplicate.AddMutations. Functions may add or remove objects, their
+// failures and results are returned as reports.
+func (o *Options) Apply(objects []*runtime.Object, sources []*resource.Info, pod *duplicate.PodOptions) ([]*runtime.Object, []Report, error) {
+	if !o.Enabled() {
+		return objects, nil, nil
+	}
+	if err := o.applyEach(objects, sources, pod); err != nil {
+		return nil, nil, err
+	}
+	functions := append([]Function{}, o.Functions...)
+	for _, command := range o.Fn {
+		fn, err := ParseFunction(command)
+		if err != nil {
+			return nil, nil, err
+		}
+		functions = append(functions, fn)
+	}
+	var reports []Report
+	for _, fn := range functions {
+		output, report, err := runFunction(fn, objects)
+		if err != nil {
+			return nil, nil, err
+		}
+		if report != nil {
+			reports = append(reports, *report)
+		}
+		if err := recordChanged(objects, output, duplicate.Mutation{Type: duplicate.MutationFunction, Value: fn.String()}); err != nil {
+			return nil, nil, err
+		}
+		objects = output
+	}
+	return objects, reports, nil
+}
+
+// applyEach changes every object in place with the patches, setters and transforms
+func (o *Options) applyEach(objects []*runtime.Object, sources []*resource.Info, pod *duplicate.PodOptions) error {
+	patches := make([]string, 0, len(o.PatchFiles)+len(o.Patches))
+	patchMutations := make([]duplicate.Mutation, 0, cap(patches))
+	for _, file := range o.PatchFiles {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		patches = append(patches, string(data))
+		patchMutations = append(patchMutations, duplicate.Mutation{Type: duplicate.MutationPatchFile, Value: file})
+	}
+	for _, patch := range o.Patches {
+		patches = append(patches, patch)
+		patchMutations = append(patchMutations, duplicate.Mutation{Type: duplicate.MutationPatch, Value: patch})
+	}
+	setters := make([]setter, 0, len(o.Set))
+	for _, expr := range o.Set {
+		s, err := parseSetter(expr)
+		if err != nil {
+			return err
+		}
+		setters = append(setters, s)
+	}
+	transforms := make([]*starlarkTransform, 0, len(o.Transforms))
+	for _, path := range o.Transforms {
+		t, err := loadTransform(path, o.ErrOut)
+		if err != nil {
+			return err
+		}
+		transforms = append(transforms, t)
+	}
+
+	// a change that doesn't fit an object, such as a field its kind lacks,
+	// skips it; it is an error when it fits none of them
+	patchErrs := make([]error, len(patches))
+	patchApplied := make([]bool, len(patches))
+	setErrs := make([]error, len(setters))
+	setApplied := make([]bool, len(setters))
+	for i, obj := range objects {
+		var source runtime.Object
+		if i < len(sources) {
+			source = sources[i].Object
+		}
+		for j, patch := range patches {
+			patched, err := applyPatch(*obj, patch, o.PatchType)
+			if err != nil {
+				patchErrs[j] = fmt.Errorf("patching %s: %v", describe(*obj), err)
+				continue
+			}
+			*obj, patchApplied[j] = patched, true
+			if err := duplicate.AddMutations(*obj, patchMutations[j]); err != nil {
+				return err
+			}
+		}
+		for j, s := range setters {
+			if !s.target.matches(*obj, source) {
+				continue
+			}
+			updated, err := s.apply(*obj)
+			if err != nil {
+				err = fmt.Errorf("--set %s on %s: %v", s.expr, describe(*obj), err)
+				// a targeted setter has to fit its targets
+				if len(s.target.kind) > 0 {
+					return err
+				}
+				setErrs[j] = err
+				continue
+			}
+			*obj, setApplied[j] = updated, true
+			if err := duplicate.AddMutations(*obj, duplicate.Mutation{Type: duplicate.MutationSet, Value: s.expr}); err != nil {
+				return err
+			}
+		}
+		if len(transforms) == 0 {
+			continue
+		}
+		ctx, err := o.transformContext(source, pod)
+		if err != nil {
+			return err
+		}
+		for j, t := range transforms {
+			transformed, err := t.transform(*obj, ctx)
+			if err != nil {
+				return fmt.Errorf("%s: %v", describe(*obj), err)
+			}
+			if !sameContent(*obj, transformed) {
+				if err := duplicate.AddMutations(transformed, duplicate.Mutation{Type: duplicate.MutationTransform, Value: o.Transforms[j]}); err != nil {
+					return err
+				}
+			}
+			*obj = transformed
+		}
+	}
+	for j := range patches {
+		if !patchApplied[j] && patchErrs[j] != nil {
+			return patchErrs[j]
+		}
+	}
+	for j, s := range setters {
+		switch {
+		case setApplied[j]:
+		case setErrs[j] != nil:
+			return setErrs[j]
+		case len(objects) > 0:
+			return fmt.Errorf("--set %s: %s matches none of the duplicates", s.expr, s.target)
+		}
+	}
+	return nil
+}
+
+// recordChanged records mutation on the objects of output that are not in
+// input, or that differ from the object of the same kind and name in input.
+func recordChanged(input, output []*runtime.Object, mutation duplicate.Mutation) error {
+	for _, obj := range output {
+		var previous runtime.Object
+		for _, in := range input {
+			if describe(*in) == describe(*obj) {
+				previous = *in
+				break
+			}
+		}
+		if previous != nil && sameContent(previous, *obj) {
+			continue
+		}
+		if err := duplicate.AddMutations(*obj, mutation); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sameContent returns whether a and b hold the same fields
+func sameContent(a, b runtime.Object) bool {
+	aContent, err := runtime.DefaultUnstructuredConverter.ToUnstructured(a)
+	if err != nil {
+		return false
+	}
+	bContent, err := runtime.DefaultUnstructuredConverter.ToUnstructured(b)
+	if err != nil {
+		return false
+	}
+	return reflect.DeepEqual(aContent, bContent)
+}
+
+// typedFor returns an empty object of the Go type of obj, or of its kind when
+// obj is unstructured and the kind is known, nil otherwise.
+func typedFor(obj runtime.Object) runtime.Object {
+	if _, ok := obj.(*unstructured.Unstructured); !ok {
+		return reflect.New(reflect.TypeOf(obj).Elem()).Interface().(runtime.Object)
+	}
+	typed, err := scheme.Scheme.New(obj.GetObjectKind().GroupVersionKind())
+	if err != nil {
+		return nil
+	}
+	return typed
+}
+
+// fromContent returns content as an object like obj. Content that does not
+// fit the type of a known kind is an error.
+func fromContent(obj runtime.Object, content map[string]interface{}) (runtime.Object, error) {
+	typed := typedFor(obj)
+	if typed != nil {
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(content, typed); err != nil {
+			return nil, err
+		}
+	}
+	if _, ok := obj.(*unstructured.Unstructured); ok {
+		return &unstructured.Unstructured{Object: content}, nil
+	}
+	return typed, nil
+}
+
+func describe(obj runtime.Object) string {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return obj.GetObjectKind().GroupVersionKind().Kind
+	}
+	u := &unstructured.Unstructured{Object: content}
+	return fmt.Sprintf("%s %q", u.GetKind(), u.GetName())
+}
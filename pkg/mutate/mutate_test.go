@@ -0,0 +1,52 @@
+package mutate
+
+import (
+	"reflect"
+	"testing"
+
+	"dup/pkg/duplicate"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/cli-runtime/pkg/genericiooptions"
+)
+
+func TestApplyEachRecordsAppliedChanges(t *testing.T) {
+	o := NewOptions(genericiooptions.NewTestIOStreamsDiscard())
+	o.Set = []string{"ConfigMap:data.mode=debug", "spec.template.spec.containers[name=app].image=app:v2"}
+	o.Patches = []string{`[{"op": "add", "path": "/data/extra", "value": "1"}]`}
+	o.PatchType = PatchJSON
+	var dep, cm runtime.Object = deployment(), configMap()
+	if err := o.applyEach([]*runtime.Object{&dep, &cm}, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		obj  runtime.Object
+		want []duplicate.Mutation
+	}{
+		{
+			obj:  dep,
+			want: []duplicate.Mutation{{Type: duplicate.MutationSet, Value: o.Set[1]}},
+		},
+		{
+			obj: cm,
+			want: []duplicate.Mutation{
+				{Type: duplicate.MutationPatch, Value: o.Patches[0]},
+				{Type: duplicate.MutationSet, Value: o.Set[0]},
+			},
+		},
+	}
+	for _, tt := range tests {
+		accessor, err := meta.Accessor(tt.obj)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := duplicate.MutationsOf(accessor)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: expected %+v, got %+v", accessor.GetName(), tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package mutate
+
+import (
+	"encoding/json"
+	"fmt"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"k8s.io/apimachinery/pkg/runtime"
+	utiljson "k8s.io/apimachinery/pkg/util/json"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"sigs.k8s.io/yaml"
+)
+
+// applyPatch returns obj patched with patch, given as YAML or JSON
+func applyPatch(obj runtime.Object, patch string, patchType string) (runtime.Object, error) {
+	patchJSON, err := yaml.YAMLToJSON([]byte(patch))
+	if err != nil {
+		return nil, fmt.Errorf("the patch is not valid YAML or JSON: %v", err)
+	}
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+	original, err := json.Marshal(content)
+	if err != nil {
+		return nil, err
+	}
+
+	var patched []byte
+	switch patchType {
+	case PatchStrategic:
+		schema := typedFor(obj)
+		if schema == nil {
+			return nil, fmt.Errorf("strategic merge patches are not supported for %s, use --patch-type %s or %s", obj.GetObjectKind().GroupVersionKind().Kind, PatchMerge, PatchJSON)
+		}
+		patched, err = strategicpatch.StrategicMergePatch(original, patchJSON, schema)
+	case PatchMerge:
+		patched, err = jsonpatch.MergePatch(original, patchJSON)
+	case PatchJSON:
+		var operations jsonpatch.Patch
+		operations, err = jsonpatch.DecodePatch(patchJSON)
+		if err != nil {
+			return nil, fmt.Errorf("the patch is not a JSON patch: %v", err)
+		}
+		patched, err = operations.Apply(original)
+	default:
+		return nil, fmt.Errorf("invalid patch type %q", patchType)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var updated map[string]interface{}
+	if err := utiljson.Unmarshal(patched, &updated); err != nil {
+		return nil, err
+	}
+	return fromContent(obj, updated)
+}
@@ -0,0 +1,339 @@
+package mutate
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	utiljson "k8s.io/apimachinery/pkg/util/json"
+	"sigs.k8s.io/yaml"
+)
+
+// step is one element of a setter path: a field, a list index, or the list
+// item whose key field has the given value.
+type step struct {
+	field string
+	index int
+	key   string
+	value string
+}
+
+func (s step) isField() bool { return len(s.field) > 0 }
+func (s step) isMatch() bool { return len(s.key) > 0 }
+
+func (s step) String() string {
+	switch {
+	case s.isField():
+		return s.field
+	case s.isMatch():
+		return fmt.Sprintf("[%s=%s]", s.key, s.value)
+	}
+	return fmt.Sprintf("[%d]", s.index)
+}
+
+// target restricts a setter to the duplicates of a kind, and of a source
+// name when name is set. The zero target matches every duplicate.
+type target struct {
+	kind string
+	name string
+}
+
+// parseTarget splits an optional KIND: or KIND/NAME: prefix off expr. A
+// prefix holding a dot without a slash is the start of a path, such as an
+// annotation key with a colon.
+func parseTarget(expr string) (target, string) {
+	prefix, rest, ok := strings.Cut(expr, ":")
+	if !ok || len(prefix) == 0 || strings.ContainsAny(prefix, "=[\\") {
+		return target{}, expr
+	}
+	kind, name, hasName := strings.Cut(prefix, "/")
+	if len(kind) == 0 || (!hasName && strings.Contains(kind, ".")) || (hasName && len(name) == 0) {
+		return target{}, expr
+	}
+	return target{kind: kind, name: name}, rest
+}
+
+func (t target) String() string {
+	if len(t.name) == 0 {
+		return t.kind
+	}
+	return t.kind + "/" + t.name
+}
+
+// matches returns whether obj, cloned from source, is targeted. The name is
+// the one of the source, or of the duplicate.
+func (t target) matches(obj, source runtime.Object) bool {
+	if len(t.kind) == 0 {
+		return true
+	}
+	if !strings.EqualFold(t.kind, obj.GetObjectKind().GroupVersionKind().Kind) {
+		return false
+	}
+	if len(t.name) == 0 {
+		return true
+	}
+	for _, o := range []runtime.Object{obj, source} {
+		if o == nil {
+			continue
+		}
+		if accessor, err := meta.Accessor(o); err == nil && accessor.GetName() == t.name {
+			return true
+		}
+	}
+	return false
+}
+
+// setter sets the value at a path such as
+// spec.template.spec.containers[name=app].env[name=X].value=1, optionally
+// prefixed with the target it is restricted to, such as Deployment/web:
+type setter struct {
+	expr   string
+	target target
+	path   []step
+	raw    string
+}
+
+// parseSetter parses [TARGET:]PATH=VALUE. Fields are separated by dots, a
+// dot in a field name is escaped with a backslash, and lists are indexed
+// with [N] or [KEY=VALUE].
+func parseSetter(expr string) (setter, error) {
+	s := setter{expr: expr}
+	s.target, expr = parseTarget(expr)
+	var field strings.Builder
+	pushField := func() {
+		if field.Len() > 0 {
+			s.path = append(s.path, step{field: field.String()})
+			field.Reset()
+		}
+	}
+	for i := 0; i < len(expr); i++ {
+		switch c := expr[i]; c {
+		case '\\':
+			if i+1 < len(expr) {
+				i++
+				field.WriteByte(expr[i])
+			}
+		case '.':
+			if field.Len() == 0 && (i == 0 || expr[i-1] != ']') {
+				return s, fmt.Errorf("invalid --set %q: empty field name", s.expr)
+			}
+			pushField()
+		case '[':
+			pushField()
+			end := strings.IndexByte(expr[i:], ']')
+			if end < 0 {
+				return s, fmt.Errorf("invalid --set %q: missing ]", s.expr)
+			}
+			selector := expr[i+1 : i+end]
+			if key, value, ok := strings.Cut(selector, "="); ok && len(key) > 0 {
+				s.path = append(s.path, step{key: key, value: value})
+			} else if index, err := strconv.Atoi(selector); err == nil && index >= 0 {
+				s.path = append(s.path, step{index: index})
+			} else {
+				return s, fmt.Errorf("invalid --set %q: [%s] must be an index or KEY=VALUE", s.expr, selector)
+			}
+			i += end
+		case '=':
+			pushField()
+			if len(s.path) == 0 {
+				return s, fmt.Errorf("invalid --set %q: empty path", s.expr)
+			}
+			s.raw = expr[i+1:]
+			return s, nil
+		default:
+			field.WriteByte(c)
+		}
+	}
+	return s, fmt.Errorf("invalid --set %q: expected PATH=VALUE", s.expr)
+}
+
+// apply returns obj with the value set. The value is parsed as YAML, unless
+// it replaces a string or does not fit the type of the field as such.
+func (s setter) apply(obj runtime.Object) (runtime.Object, error) {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+	var lastErr error
+	for _, value := range s.values(content) {
+		updated, err := set(runtime.DeepCopyJSON(content), s.path, value)
+		if err != nil {
+			return nil, err
+		}
+		result, err := fromContent(obj, updated.(map[string]interface{}))
+		if err != nil {
+			lastErr = fmt.Errorf("%s doesn't fit the field %s: %v", s.raw, s.pathString(), err)
+			continue
+		}
+		// known kinds drop unknown fields when converted, make sure the value stuck
+		if typed := typedFor(obj); typed != nil {
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(updated.(map[string]interface{}), typed); err != nil {
+				return nil, err
+			}
+			converted, err := runtime.DefaultUnstructuredConverter.ToUnstructured(typed)
+			if err != nil {
+				return nil, err
+			}
+			if got, _ := get(converted, s.path); !covers(got, value) {
+				lastErr = fmt.Errorf("%s is not a field of %s", s.pathString(), obj.GetObjectKind().GroupVersionKind().Kind)
+				continue
+			}
+		}
+		return result, nil
+	}
+	return nil, lastErr
+}
+
+// values returns the candidate values of the setter, in order of preference
+func (s setter) values(content map[string]interface{}) []interface{} {
+	// null removes the field, whatever its type
+	if s.raw == "null" || s.raw == "~" {
+		return []interface{}{nil}
+	}
+	if existing, found := get(content, s.path); found {
+		if _, ok := existing.(string); ok {
+			return []interface{}{s.raw}
+		}
+	}
+	if len(s.raw) == 0 {
+		return []interface{}{s.raw}
+	}
+	data, err := yaml.YAMLToJSON([]byte(s.raw))
+	if err != nil {
+		return []interface{}{s.raw}
+	}
+	var decoded interface{}
+	if err := utiljson.Unmarshal(data, &decoded); err != nil || decoded == s.raw {
+		return []interface{}{s.raw}
+	}
+	return []interface{}{decoded, s.raw}
+}
+
+func (s setter) pathString() string {
+	var b strings.Builder
+	for i, st := range s.path {
+		if i > 0 && st.isField() {
+			b.WriteByte('.')
+		}
+		b.WriteString(st.String())
+	}
+	return b.String()
+}
+
+// set returns node with value set at path, creating missing objects and list
+// items matched by key. A nil value removes the field or list item.
+func set(node interface{}, path []step, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	st := path[0]
+	if st.isField() {
+		m, ok := node.(map[string]interface{})
+		if node == nil {
+			m, ok = map[string]interface{}{}, true
+		}
+		if !ok {
+			return nil, fmt.Errorf("can't set %s, the parent is not an object", st)
+		}
+		if len(path) == 1 && value == nil {
+			delete(m, st.field)
+			return m, nil
+		}
+		child, err := set(m[st.field], path[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		m[st.field] = child
+		return m, nil
+	}
+
+	list, ok := node.([]interface{})
+	if node == nil {
+		ok = true
+	}
+	if !ok {
+		return nil, fmt.Errorf("can't select %s, the parent is not a list", st)
+	}
+	i := st.index
+	if st.isMatch() {
+		i = matchIndex(list, st)
+		if i < 0 {
+			if value == nil && len(path) == 1 {
+				return list, nil
+			}
+			list = append(list, map[string]interface{}{st.key: st.value})
+			i = len(list) - 1
+		}
+	} else if i >= len(list) {
+		return nil, fmt.Errorf("index %s out of range, the list has %d items", st, len(list))
+	}
+	if value == nil && len(path) == 1 {
+		return append(list[:i], list[i+1:]...), nil
+	}
+	child, err := set(list[i], path[1:], value)
+	if err != nil {
+		return nil, err
+	}
+	list[i] = child
+	return list, nil
+}
+
+// get returns the value at path in node
+func get(node interface{}, path []step) (interface{}, bool) {
+	for _, st := range path {
+		if st.isField() {
+			m, ok := node.(map[string]interface{})
+			if !ok {
+				return nil, false
+			}
+			if node, ok = m[st.field]; !ok {
+				return nil, false
+			}
+			continue
+		}
+		list, ok := node.([]interface{})
+		if !ok {
+			return nil, false
+		}
+		i := st.index
+		if st.isMatch() {
+			i = matchIndex(list, st)
+		}
+		if i < 0 || i >= len(list) {
+			return nil, false
+		}
+		node = list[i]
+	}
+	return node, true
+}
+
+func matchIndex(list []interface{}, st step) int {
+	for i, item := range list {
+		if m, ok := item.(map[string]interface{}); ok && m[st.key] != nil && fmt.Sprint(m[st.key]) == st.value {
+			return i
+		}
+	}
+	return -1
+}
+
+// covers returns whether got holds want, ignoring fields added to objects
+// when converting, such as empty defaults.
+func covers(got, want interface{}) bool {
+	wantMap, ok := want.(map[string]interface{})
+	if !ok {
+		return reflect.DeepEqual(got, want)
+	}
+	gotMap, ok := got.(map[string]interface{})
+	if !ok {
+		return len(wantMap) == 0
+	}
+	for key, value := range wantMap {
+		if !covers(gotMap[key], value) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,377 @@
+package mutate
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/cli-runtime/pkg/genericiooptions"
+)
+
+func TestParseSetter(t *testing.T) {
+	tests := []struct {
+		expr   string
+		target target
+		path   []step
+		raw    string
+		err    string
+	}{
+		{
+			expr: "spec.replicas=1",
+			path: []step{{field: "spec"}, {field: "replicas"}},
+			raw:  "1",
+		},
+		{
+			expr: "spec.template.spec.containers[name=app].env[name=X].value=a=b",
+			path: []step{
+				{field: "spec"}, {field: "template"}, {field: "spec"},
+				{field: "containers"}, {key: "name", value: "app"},
+				{field: "env"}, {key: "name", value: "X"}, {field: "value"},
+			},
+			raw: "a=b",
+		},
+		{
+			expr: "spec.containers[0].args[1]=--debug",
+			path: []step{{field: "spec"}, {field: "containers"}, {index: 0}, {field: "args"}, {index: 1}},
+			raw:  "--debug",
+		},
+		{
+			expr: `metadata.annotations.example\.com/team=payments`,
+			path: []step{{field: "metadata"}, {field: "annotations"}, {field: "example.com/team"}},
+			raw:  "payments",
+		},
+		{
+			expr: "metadata.labels.app=",
+			path: []step{{field: "metadata"}, {field: "labels"}, {field: "app"}},
+		},
+		{
+			expr:   "Deployment/web:spec.replicas=2",
+			target: target{kind: "Deployment", name: "web"},
+			path:   []step{{field: "spec"}, {field: "replicas"}},
+			raw:    "2",
+		},
+		{
+			expr:   "configmap:data.mode=debug",
+			target: target{kind: "configmap"},
+			path:   []step{{field: "data"}, {field: "mode"}},
+			raw:    "debug",
+		},
+		{
+			// a colon in a path is not a target
+			expr: "metadata.annotations.a:b=1",
+			path: []step{{field: "metadata"}, {field: "annotations"}, {field: "a:b"}},
+			raw:  "1",
+		},
+		{
+			expr: "spec.containers[name=a:b].image=x",
+			path: []step{{field: "spec"}, {field: "containers"}, {key: "name", value: "a:b"}, {field: "image"}},
+			raw:  "x",
+		},
+		{
+			expr: "spec.image=registry:5000/app:v1",
+			path: []step{{field: "spec"}, {field: "image"}},
+			raw:  "registry:5000/app:v1",
+		},
+		{expr: "spec.replicas", err: "expected PATH=VALUE"},
+		{expr: "=1", err: "empty path"},
+		{expr: "spec..replicas=1", err: "empty field name"},
+		{expr: ".spec=1", err: "empty field name"},
+		{expr: "spec.containers[0.image=x", err: "missing ]"},
+		{expr: "spec.containers[-1].image=x", err: "must be an index or KEY=VALUE"},
+		{expr: "spec.containers[].image=x", err: "must be an index or KEY=VALUE"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			s, err := parseSetter(tt.expr)
+			if len(tt.err) > 0 {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("expected an error containing %q, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if s.target != tt.target {
+				t.Errorf("target: expected %+v, got %+v", tt.target, s.target)
+			}
+			if !reflect.DeepEqual(s.path, tt.path) {
+				t.Errorf("path: expected %+v, got %+v", tt.path, s.path)
+			}
+			if s.raw != tt.raw {
+				t.Errorf("value: expected %q, got %q", tt.raw, s.raw)
+			}
+		})
+	}
+}
+
+func deployment() *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata": map[string]interface{}{
+			"name":   "web",
+			"labels": map[string]interface{}{"app": "web"},
+		},
+		"spec": map[string]interface{}{
+			"replicas": int64(3),
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": []interface{}{
+						map[string]interface{}{
+							"name":  "app",
+							"image": "app:v1",
+							"env":   []interface{}{map[string]interface{}{"name": "MODE", "value": "prod"}},
+						},
+						map[string]interface{}{"name": "sidecar", "image": "proxy:v1"},
+					},
+				},
+			},
+		},
+	}}
+}
+
+func configMap() *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   map[string]interface{}{"name": "settings"},
+		"data":       map[string]interface{}{"mode": "prod"},
+	}}
+}
+
+func TestSetterApply(t *testing.T) {
+	tests := []struct {
+		name string
+		obj  *unstructured.Unstructured
+		expr string
+		path []string
+		want interface{}
+		err  string
+	}{
+		{
+			name: "integer",
+			obj:  deployment(),
+			expr: "spec.replicas=1",
+			path: []string{"spec", "replicas"},
+			want: int64(1),
+		},
+		{
+			name: "selected container",
+			obj:  deployment(),
+			expr: "spec.template.spec.containers[name=sidecar].image=proxy:v2",
+			path: []string{"spec", "template", "spec", "containers"},
+			want: []interface{}{
+				map[string]interface{}{
+					"name":  "app",
+					"image": "app:v1",
+					"env":   []interface{}{map[string]interface{}{"name": "MODE", "value": "prod"}},
+				},
+				map[string]interface{}{"name": "sidecar", "image": "proxy:v2"},
+			},
+		},
+		{
+			name: "string field keeps a number as string",
+			obj:  deployment(),
+			expr: "spec.template.spec.containers[0].image=1234",
+			path: []string{"spec", "template", "spec", "containers"},
+			want: []interface{}{
+				map[string]interface{}{
+					"name":  "app",
+					"image": "1234",
+					"env":   []interface{}{map[string]interface{}{"name": "MODE", "value": "prod"}},
+				},
+				map[string]interface{}{"name": "sidecar", "image": "proxy:v1"},
+			},
+		},
+		{
+			name: "new label falls back to string",
+			obj:  deployment(),
+			expr: "metadata.labels.debug=true",
+			path: []string{"metadata", "labels"},
+			want: map[string]interface{}{"app": "web", "debug": "true"},
+		},
+		{
+			name: "missing field is added",
+			obj:  configMap(),
+			expr: "data.extra=1",
+			path: []string{"data"},
+			want: map[string]interface{}{"mode": "prod", "extra": "1"},
+		},
+		{
+			name: "null removes the field",
+			obj:  configMap(),
+			expr: "data.mode=null",
+			path: []string{"data"},
+			want: map[string]interface{}{},
+		},
+		{
+			name: "missing list is created by key",
+			obj:  deployment(),
+			expr: "spec.template.spec.containers[name=sidecar].env[name=A].value=b",
+			path: []string{"spec", "template", "spec", "containers"},
+			want: []interface{}{
+				map[string]interface{}{
+					"name":  "app",
+					"image": "app:v1",
+					"env":   []interface{}{map[string]interface{}{"name": "MODE", "value": "prod"}},
+				},
+				map[string]interface{}{"name": "sidecar", "image": "proxy:v1", "env": []interface{}{map[string]interface{}{"name": "A", "value": "b"}}},
+			},
+		},
+		{
+			name: "missing list by index",
+			obj:  deployment(),
+			expr: "spec.template.spec.containers[name=sidecar].args[0]=-v",
+			err:  "out of range, the list has 0 items",
+		},
+		{
+			name: "index out of range",
+			obj:  deployment(),
+			expr: "spec.template.spec.containers[5].image=x",
+			err:  "out of range, the list has 2 items",
+		},
+		{
+			name: "list index on an object",
+			obj:  deployment(),
+			expr: "spec.replicas[0]=1",
+			err:  "not a list",
+		},
+		{
+			name: "field of another kind",
+			obj:  configMap(),
+			expr: "spec.template.spec.containers[name=app].image=x",
+			err:  "is not a field of ConfigMap",
+		},
+		{
+			name: "type mismatch",
+			obj:  deployment(),
+			expr: "spec.replicas=[1, 2]",
+			err:  "replicas",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := parseSetter(tt.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			result, err := s.apply(tt.obj)
+			if len(tt.err) > 0 {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("expected an error containing %q, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, _, _ := unstructured.NestedFieldNoCopy(result.(*unstructured.Unstructured).Object, tt.path...)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %#v, got %#v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSetterAddsMatchedItem(t *testing.T) {
+	s, err := parseSetter("spec.template.spec.containers[name=app].env[name=DEBUG].value=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := s.apply(deployment())
+	if err != nil {
+		t.Fatal(err)
+	}
+	containers, _, _ := unstructured.NestedSlice(result.(*unstructured.Unstructured).Object, "spec", "template", "spec", "containers")
+	env := containers[0].(map[string]interface{})["env"]
+	want := []interface{}{
+		map[string]interface{}{"name": "MODE", "value": "prod"},
+		map[string]interface{}{"name": "DEBUG", "value": "1"},
+	}
+	if !reflect.DeepEqual(env, want) {
+		t.Errorf("expected %#v, got %#v", want, env)
+	}
+}
+
+func TestApplyEachSkipsObjectsWithoutTheField(t *testing.T) {
+	tests := []struct {
+		name      string
+		set       []string
+		patches   []string
+		patchType string
+		image     string
+		mode      string
+		err       string
+	}{
+		{
+			name:  "setter skips the kind without the field",
+			set:   []string{"spec.template.spec.containers[name=app].image=app:v2"},
+			image: "app:v2",
+			mode:  "prod",
+		},
+		{
+			name:  "targeted setter",
+			set:   []string{"ConfigMap/settings:data.mode=debug", "deployment:spec.template.spec.containers[0].image=app:v3"},
+			image: "app:v3",
+			mode:  "debug",
+		},
+		{
+			name:      "patch skips the kind it doesn't apply to",
+			patches:   []string{`[{"op": "replace", "path": "/data/mode", "value": "debug"}]`},
+			patchType: PatchJSON,
+			image:     "app:v1",
+			mode:      "debug",
+		},
+		{
+			name: "setter fitting no object",
+			set:  []string{"spec.template.spec.containers[7].image=x"},
+			err:  "out of range",
+		},
+		{
+			name: "target matching no object",
+			set:  []string{"Secret:data.mode=debug"},
+			err:  "matches none of the duplicates",
+		},
+		{
+			name: "targeted setter not fitting its target",
+			set:  []string{"ConfigMap:spec.replicas=1"},
+			err:  "is not a field of ConfigMap",
+		},
+		{
+			name:      "patch fitting no object",
+			patches:   []string{`[{"op": "replace", "path": "/missing/field", "value": 1}]`},
+			patchType: PatchJSON,
+			err:       "patching",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := NewOptions(genericiooptions.NewTestIOStreamsDiscard())
+			o.Set, o.Patches = tt.set, tt.patches
+			if len(tt.patchType) > 0 {
+				o.PatchType = tt.patchType
+			}
+			var dep, cm runtime.Object = deployment(), configMap()
+			err := o.applyEach([]*runtime.Object{&dep, &cm}, nil, nil)
+			if len(tt.err) > 0 {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("expected an error containing %q, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			containers, _, _ := unstructured.NestedSlice(dep.(*unstructured.Unstructured).Object, "spec", "template", "spec", "containers")
+			if image := containers[0].(map[string]interface{})["image"]; image != tt.image {
+				t.Errorf("image: expected %q, got %q", tt.image, image)
+			}
+			if mode, _, _ := unstructured.NestedString(cm.(*unstructured.Unstructured).Object, "data", "mode"); mode != tt.mode {
+				t.Errorf("mode: expected %q, got %q", tt.mode, mode)
+			}
+		})
+	}
+}
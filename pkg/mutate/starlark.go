@@ -0,0 +1,234 @@
+package mutate
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"dup/pkg/duplicate"
+
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+	"go.starlark.net/syntax"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// transformFunction is the function every transform script has to define
+const transformFunction = "transform"
+
+// starlarkTransform runs the transform function of a Starlark script, called
+// as transform(obj, ctx) with the duplicate as a dict and ctx holding the
+// source object and the mutation options. The function changes obj in place
+// or returns the new object.
+type starlarkTransform struct {
+	name   string
+	fn     starlark.Callable
+	errOut io.Writer
+}
+
+// loadTransform executes the script at path and returns its transform
+// function, what the script prints goes to errOut
+func loadTransform(path string, errOut io.Writer) (*starlarkTransform, error) {
+	name := filepath.Base(path)
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	thread := newThread(name, errOut)
+	opts := &syntax.FileOptions{Set: true, While: true, TopLevelControl: true, GlobalReassign: true, Recursion: true}
+	globals, err := starlark.ExecFileOptions(opts, thread, path, src, nil)
+	if err != nil {
+		return nil, fmt.Errorf("transform %s: %v", path, starlarkError(err))
+	}
+	fn, ok := globals[transformFunction].(starlark.Callable)
+	if !ok {
+		return nil, fmt.Errorf("transform %s does not define a %s(obj, ctx) function", path, transformFunction)
+	}
+	return &starlarkTransform{name: name, fn: fn, errOut: errOut}, nil
+}
+
+// transform returns obj as changed by the script
+func (t *starlarkTransform) transform(obj runtime.Object, ctx *starlarkstruct.Struct) (runtime.Object, error) {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+	value, err := toStarlark(content)
+	if err != nil {
+		return nil, err
+	}
+	result, err := starlark.Call(newThread(t.name, t.errOut), t.fn, starlark.Tuple{value, ctx}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("transform %s: %v", t.name, starlarkError(err))
+	}
+	if result == starlark.None {
+		result = value
+	}
+	converted, err := fromStarlark(result)
+	if err != nil {
+		return nil, fmt.Errorf("transform %s: %v", t.name, err)
+	}
+	updated, ok := converted.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("transform %s returned a %s instead of an object", t.name, result.Type())
+	}
+	return fromContent(obj, updated)
+}
+
+// transformContext returns the ctx argument of transform functions
+func (o *Options) transformContext(source runtime.Object, pod *duplicate.PodOptions) (*starlarkstruct.Struct, error) {
+	sourceValue := starlark.Value(starlark.None)
+	if source != nil {
+		content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(source)
+		if err != nil {
+			return nil, err
+		}
+		if sourceValue, err = toStarlark(content); err != nil {
+			return nil, err
+		}
+	}
+	if pod == nil {
+		pod = &duplicate.PodOptions{}
+	}
+	options := starlarkstruct.FromStringDict(starlarkstruct.Default, starlark.StringDict{
+		"pod":            starlark.Bool(pod.DuplicateInnerPod),
+		"disable_probes": starlark.Bool(pod.DisableProbes),
+		"command_loop":   starlark.Bool(pod.LoopCommand),
+		"image":          starlark.String(pod.Image),
+		"ttl":            starlark.String(ttlString(pod)),
+		"set":            stringList(o.Set),
+		"patches":        stringList(o.Patches),
+		"patch_files":    stringList(o.PatchFiles),
+		"patch_type":     starlark.String(o.PatchType),
+	})
+	return starlarkstruct.FromStringDict(starlarkstruct.Default, starlark.StringDict{
+		"source":  sourceValue,
+		"options": options,
+	}), nil
+}
+
+func ttlString(pod *duplicate.PodOptions) string {
+	if pod.TTL == 0 {
+		return ""
+	}
+	return pod.TTL.String()
+}
+
+func newThread(name string, errOut io.Writer) *starlark.Thread {
+	return &starlark.Thread{
+		Name: name,
+		Print: func(_ *starlark.Thread, msg string) {
+			fmt.Fprintf(errOut, "%s: %s\n", name, msg)
+		},
+	}
+}
+
+// starlarkError adds the backtrace to errors raised by scripts
+func starlarkError(err error) error {
+	var evalErr *starlark.EvalError
+	if errors.As(err, &evalErr) {
+		return errors.New(evalErr.Backtrace())
+	}
+	return err
+}
+
+func stringList(values []string) *starlark.List {
+	elems := make([]starlark.Value, 0, len(values))
+	for _, v := range values {
+		elems = append(elems, starlark.String(v))
+	}
+	return starlark.NewList(elems)
+}
+
+// toStarlark converts unstructured content to Starlark values
+func toStarlark(value interface{}) (starlark.Value, error) {
+	switch v := value.(type) {
+	case nil:
+		return starlark.None, nil
+	case bool:
+		return starlark.Bool(v), nil
+	case string:
+		return starlark.String(v), nil
+	case int64:
+		return starlark.MakeInt64(v), nil
+	case float64:
+		return starlark.Float(v), nil
+	case []interface{}:
+		elems := make([]starlark.Value, 0, len(v))
+		for _, item := range v {
+			elem, err := toStarlark(item)
+			if err != nil {
+				return nil, err
+			}
+			elems = append(elems, elem)
+		}
+		return starlark.NewList(elems), nil
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		dict := starlark.NewDict(len(v))
+		for _, key := range keys {
+			elem, err := toStarlark(v[key])
+			if err != nil {
+				return nil, err
+			}
+			if err := dict.SetKey(starlark.String(key), elem); err != nil {
+				return nil, err
+			}
+		}
+		return dict, nil
+	}
+	return nil, fmt.Errorf("unsupported value %v of type %T", value, value)
+}
+
+// fromStarlark converts Starlark values back to unstructured content
+func fromStarlark(value starlark.Value) (interface{}, error) {
+	switch v := value.(type) {
+	case starlark.NoneType:
+		return nil, nil
+	case starlark.Bool:
+		return bool(v), nil
+	case starlark.String:
+		return string(v), nil
+	case starlark.Int:
+		i, ok := v.Int64()
+		if !ok {
+			return nil, fmt.Errorf("integer %s is out of range", v)
+		}
+		return i, nil
+	case starlark.Float:
+		return float64(v), nil
+	case *starlark.List, starlark.Tuple:
+		iterable := v.(starlark.Indexable)
+		items := make([]interface{}, 0, iterable.Len())
+		for i := 0; i < iterable.Len(); i++ {
+			item, err := fromStarlark(iterable.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	case *starlark.Dict:
+		m := make(map[string]interface{}, v.Len())
+		for _, item := range v.Items() {
+			key, ok := item[0].(starlark.String)
+			if !ok {
+				return nil, fmt.Errorf("object keys must be strings, got %s", item[0].Type())
+			}
+			elem, err := fromStarlark(item[1])
+			if err != nil {
+				return nil, err
+			}
+			m[string(key)] = elem
+		}
+		return m, nil
+	}
+	return nil, fmt.Errorf("unsupported value of type %s", value.Type())
+}
@@ -0,0 +1,173 @@
+package mutate
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"go.starlark.net/starlark"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/cli-runtime/pkg/genericiooptions"
+)
+
+func TestStarlarkRoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		// kind is the Starlark type the value converts to
+		kind string
+	}{
+		{name: "null", value: nil, kind: "NoneType"},
+		{name: "bool", value: true, kind: "bool"},
+		{name: "string", value: "app:v1", kind: "string"},
+		{name: "int64", value: int64(3), kind: "int"},
+		{name: "large int64", value: int64(1) << 62, kind: "int"},
+		{name: "float", value: 0.5, kind: "float"},
+		{name: "whole float", value: float64(3), kind: "float"},
+		{name: "nested list", value: []interface{}{int64(1), []interface{}{"a", 1.5}, map[string]interface{}{"b": nil}}, kind: "list"},
+		{
+			name: "nested dict",
+			value: map[string]interface{}{
+				"spec": map[string]interface{}{
+					"replicas":   int64(2),
+					"containers": []interface{}{map[string]interface{}{"name": "app", "ports": []interface{}{int64(8080)}}},
+				},
+			},
+			kind: "dict",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := toStarlark(tt.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if value.Type() != tt.kind {
+				t.Errorf("expected a %s, got a %s", tt.kind, value.Type())
+			}
+			got, err := fromStarlark(value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.value) {
+				t.Errorf("expected %#v, got %#v", tt.value, got)
+			}
+		})
+	}
+}
+
+func TestFromStarlarkErrors(t *testing.T) {
+	dict := starlark.NewDict(1)
+	if err := dict.SetKey(starlark.MakeInt(1), starlark.True); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name  string
+		value starlark.Value
+	}{
+		{name: "integer out of range", value: starlark.MakeUint64(1 << 63)},
+		{name: "non-string key", value: dict},
+		{name: "unsupported type", value: starlark.NewSet(0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := fromStarlark(tt.value); err == nil {
+				t.Errorf("expected an error")
+			}
+		})
+	}
+}
+
+func TestTransform(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		want   map[string]interface{}
+		// printed is what the script writes to ErrOut
+		printed string
+		err     bool
+	}{
+		{
+			name: "changed in place",
+			script: `
+def transform(obj, ctx):
+    obj["data"]["mode"] = "debug"
+    obj["data"]["keys"] = str(len(obj["data"]))
+`,
+			want: map[string]interface{}{"mode": "debug", "keys": "1"},
+		},
+		{
+			name: "new object returned",
+			script: `
+def transform(obj, ctx):
+    return {"apiVersion": "v1", "kind": "ConfigMap", "metadata": obj["metadata"], "data": {"ratio": "0.5"}}
+`,
+			want: map[string]interface{}{"ratio": "0.5"},
+		},
+		{
+			name: "None keeps the object",
+			script: `
+def transform(obj, ctx):
+    print("source", ctx.source["data"]["mode"], ctx.options.set)
+    return None
+`,
+			want:    map[string]interface{}{"mode": "prod"},
+			printed: "transform.star: source prod [\"data.mode=debug\"]\n",
+		},
+		{
+			name: "not an object",
+			script: `
+def transform(obj, ctx):
+    return [obj]
+`,
+			err: true,
+		},
+		{
+			name: "failure",
+			script: `
+def transform(obj, ctx):
+    fail("no")
+`,
+			err: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "transform.star")
+			if err := os.WriteFile(path, []byte(tt.script), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			streams, _, _, errOut := genericiooptions.NewTestIOStreams()
+			o := NewOptions(streams)
+			o.Set = []string{"data.mode=debug"}
+			transform, err := loadTransform(path, o.ErrOut)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ctx, err := o.transformContext(configMap(), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var obj runtime.Object = configMap()
+			transformed, err := transform.transform(obj, ctx)
+			if tt.err {
+				if err == nil {
+					t.Errorf("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, _, _ := unstructured.NestedMap(transformed.(*unstructured.Unstructured).Object, "data")
+			if !reflect.DeepEqual(data, tt.want) {
+				t.Errorf("expected %#v, got %#v", tt.want, data)
+			}
+			if errOut.String() != tt.printed {
+				t.Errorf("expected %q printed, got %q", tt.printed, errOut.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,24 @@
+#!/bin/sh
+# A KRM function for the tests of runFunction, behaving as its first argument says
+case "$1" in
+debug)
+	sed 's/mode: prod/mode: debug/'
+	;;
+json)
+	cat >/dev/null
+	echo '{"apiVersion": "config.kubernetes.io/v1", "kind": "ResourceList", "items": [{"apiVersion": "apps/v1", "kind": "Deployment", "metadata": {"name": "web"}, "spec": {"replicas": 2}}]}'
+	;;
+warning | error)
+	cat
+	printf 'results:\n- message: mode is prod\n  severity: %s\n  resourceRef:\n    kind: ConfigMap\n    name: settings\n' "$1"
+	;;
+fail)
+	cat >/dev/null
+	echo "cannot reach the registry" >&2
+	exit 1
+	;;
+invalid)
+	cat >/dev/null
+	echo "done"
+	;;
+esac
@@ -0,0 +1,167 @@
+// Package preserved tracks the files edit sessions were preserved to when
+// they failed or were cancelled, with the command line that started them, so
+// that they can be resumed.
+package preserved
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// CredentialFlags hold secrets, they are never recorded
+var CredentialFlags = []string{"token", "password", "client-key"}
+
+// Record describes a preserved edit session
+type Record struct {
+	// File holds the edited objects
+	File string `json:"file"`
+	// Args are the arguments of the command that started the session,
+	// without credentials
+	Args []string `json:"args"`
+	// Group is the invocation group of the duplicates the session created
+	Group string    `json:"group,omitempty"`
+	Time  time.Time `json:"time"`
+}
+
+// Dir returns the directory holding the records, in $XDG_STATE_HOME or ~/.local/state
+func Dir() (string, error) {
+	dir := os.Getenv("XDG_STATE_HOME")
+	if len(dir) == 0 {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, ".local", "state")
+	}
+	return filepath.Join(dir, "kubectl-dup", "preserved"), nil
+}
+
+// Save records that the session started by args, creating duplicates in
+// group, was preserved to file. The credentials given as flags are left out.
+func Save(file string, args []string, group string, now time.Time) error {
+	file, err := filepath.Abs(file)
+	if err != nil {
+		return err
+	}
+	path, err := recordPath(file)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	data, err := json.Marshal(Record{File: file, Args: withoutCredentials(args), Group: group, Time: now})
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+// Find returns the record of file
+func Find(file string) (*Record, error) {
+	file, err := filepath.Abs(file)
+	if err != nil {
+		return nil, err
+	}
+	path, err := recordPath(file)
+	if err != nil {
+		return nil, err
+	}
+	r, err := load(path)
+	if errors.Is(err, fs.ErrNotExist) || (err == nil && r.File != file) {
+		return nil, fmt.Errorf("%s was not preserved by an edit session, its options are unknown", file)
+	}
+	return r, err
+}
+
+// Latest returns the most recent record whose file still exists
+func Latest() (*Record, error) {
+	dir, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	var latest *Record
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		r, err := load(path)
+		if err != nil {
+			continue
+		}
+		// the file was deleted, or the temporary directory cleaned
+		if _, err := os.Stat(r.File); errors.Is(err, fs.ErrNotExist) {
+			os.Remove(path)
+			continue
+		}
+		if latest == nil || r.Time.After(latest.Time) {
+			latest = r
+		}
+	}
+	if latest == nil {
+		return nil, fmt.Errorf("no preserved edit session found")
+	}
+	return latest, nil
+}
+
+// Remove deletes the record of file, and file itself
+func Remove(file string) error {
+	path, err := recordPath(file)
+	if err != nil {
+		return err
+	}
+	for _, p := range []string{path, file} {
+		if err := os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// withoutCredentials returns args without the CredentialFlags and their values
+func withoutCredentials(args []string) []string {
+	ret := []string{}
+	for i := 0; i < len(args); i++ {
+		name, _, hasValue := strings.Cut(strings.TrimLeft(args[i], "-"), "=")
+		if !strings.HasPrefix(args[i], "--") || !slices.Contains(CredentialFlags, name) {
+			ret = append(ret, args[i])
+			continue
+		}
+		if !hasValue {
+			// the value is the next argument
+			i++
+		}
+	}
+	return ret
+}
+
+// recordPath returns where the record of file is kept, named after the
+// unique name of the temporary file
+func recordPath(file string) (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, filepath.Base(file)+".json"), nil
+}
+
+func load(path string) (*Record, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	r := &Record{}
+	if err := json.Unmarshal(data, r); err != nil {
+		return nil, fmt.Errorf("invalid record %s: %v", path, err)
+	}
+	return r, nil
+}
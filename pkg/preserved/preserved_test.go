@@ -0,0 +1,56 @@
+package preserved
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWithoutCredentials(t *testing.T) {
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{args: []string{"deployment", "web", "-l"}, want: []string{"deployment", "web", "-l"}},
+		{args: []string{"deployment", "web", "--token=secret", "-n", "shop"}, want: []string{"deployment", "web", "-n", "shop"}},
+		{args: []string{"--token", "secret", "deployment", "web"}, want: []string{"deployment", "web"}},
+		{args: []string{"deployment", "web", "--password", "secret", "--username=me"}, want: []string{"deployment", "web", "--username=me"}},
+		{args: []string{"deployment", "web", "--client-key=/home/me/key.pem", "--client-certificate=/home/me/cert.pem"}, want: []string{"deployment", "web", "--client-certificate=/home/me/cert.pem"}},
+		{args: []string{"deployment", "web", "--token"}, want: []string{"deployment", "web"}},
+		{args: []string{"deployment", "token"}, want: []string{"deployment", "token"}},
+	}
+	for _, tt := range tests {
+		if got := withoutCredentials(tt.args); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%v: expected %v, got %v", tt.args, tt.want, got)
+		}
+	}
+}
+
+func TestSaveFind(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	file := filepath.Join(t.TempDir(), "kubectl-dup-edit-123.yaml")
+	if err := os.WriteFile(file, []byte("kind: List\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	if err := Save(file, []string{"deployment", "web", "--token=secret"}, "group-1", now); err != nil {
+		t.Fatal(err)
+	}
+	record, err := Find(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Record{File: file, Args: []string{"deployment", "web"}, Group: "group-1", Time: now}
+	if !reflect.DeepEqual(record, want) {
+		t.Errorf("expected %+v, got %+v", want, record)
+	}
+	latest, err := Latest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if latest.File != file {
+		t.Errorf("expected the latest record of %s, got %s", file, latest.File)
+	}
+}
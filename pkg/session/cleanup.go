@@ -0,0 +1,70 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"dup/pkg/duplicate"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/kubectl/pkg/util/interrupt"
+)
+
+// cleanupHandler returns an interrupt handler deleting everything the
+// invocation created when the process is signalled or the handler is closed.
+// Deletion happens at most once. The handler does not exit the process, the
+// remote session ends by itself once its pod is deleted.
+func (o *Options) cleanupHandler(group string, created []*resource.Info) (*interrupt.Handler, func()) {
+	var once sync.Once
+	cleanup := func() {
+		once.Do(func() {
+			o.deleteCreated(group, created)
+		})
+	}
+	return interrupt.New(func(os.Signal) {}, cleanup), cleanup
+}
+
+// deleteCreated deletes the created objects, and the claims provisioned for
+// them, reporting failures without stopping.
+func (o *Options) deleteCreated(group string, created []*resource.Info) {
+	policy := metav1.DeletePropagationBackground
+	deleted := map[string]bool{}
+	for _, info := range created {
+		if info.Mapping.GroupVersionKind.Kind == "PersistentVolumeClaim" {
+			deleted[info.Name] = true
+		}
+		_, err := resource.NewHelper(info.Client, info.Mapping).
+			DeleteWithOptions(info.Namespace, info.Name, &metav1.DeleteOptions{PropagationPolicy: &policy})
+		if err != nil && !apierrors.IsNotFound(err) {
+			fmt.Fprintf(o.ErrOut, "error: unable to delete %s %q: %v\n", strings.ToLower(info.Mapping.GroupVersionKind.Kind), info.Name, err)
+			continue
+		}
+		fmt.Fprintf(o.ErrOut, "%s %q deleted\n", strings.ToLower(info.Mapping.GroupVersionKind.Kind), info.Name)
+	}
+
+	if len(group) == 0 {
+		return
+	}
+	claims := o.client.CoreV1().PersistentVolumeClaims(o.namespace)
+	list, err := claims.List(context.TODO(), metav1.ListOptions{LabelSelector: duplicate.GroupLabel + "=" + group})
+	if err != nil {
+		fmt.Fprintf(o.ErrOut, "error: unable to list persistent volume claims: %v\n", err)
+		return
+	}
+	for _, claim := range list.Items {
+		if deleted[claim.Name] {
+			continue
+		}
+		err := claims.Delete(context.TODO(), claim.Name, metav1.DeleteOptions{PropagationPolicy: &policy})
+		if err != nil && !apierrors.IsNotFound(err) {
+			fmt.Fprintf(o.ErrOut, "error: unable to delete persistentvolumeclaim %q: %v\n", claim.Name, err)
+			continue
+		}
+		fmt.Fprintf(o.ErrOut, "persistentvolumeclaim %q deleted\n", claim.Name)
+	}
+}
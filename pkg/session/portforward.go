@@ -0,0 +1,122 @@
+package session
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/transport/spdy"
+)
+
+// AutoPortForward forwards every declared containerPort to a free local port
+const AutoPortForward = "auto"
+
+// portForward forwards o.PortForward to pod in the background and prints
+// the local URLs once ready. The returned channel receives the result of
+// the forwarder when it stops, stop closes it.
+func (o *Options) portForward(pod *corev1.Pod) (<-chan error, func(), error) {
+	ports, err := forwardedPorts(pod, o.PortForward)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	transport, upgrader, err := spdy.RoundTripperFor(o.config)
+	if err != nil {
+		return nil, nil, err
+	}
+	req := o.client.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(pod.Namespace).
+		Name(pod.Name).
+		SubResource("portforward")
+	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, http.MethodPost, req.URL())
+
+	stopChan := make(chan struct{})
+	readyChan := make(chan struct{})
+	forwarder, err := portforward.NewOnAddresses(dialer, []string{"localhost"}, ports, stopChan, readyChan, io.Discard, o.ErrOut)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- forwarder.ForwardPorts()
+	}()
+	select {
+	case <-readyChan:
+	case err := <-done:
+		return nil, nil, err
+	}
+
+	forwarded, err := forwarder.GetPorts()
+	if err != nil {
+		close(stopChan)
+		return nil, nil, err
+	}
+	for _, port := range forwarded {
+		fmt.Fprintf(o.ErrOut, "Forwarding http://localhost:%d -> pod/%s:%d\n", port.Local, pod.Name, port.Remote)
+	}
+	stopped := false
+	stop := func() {
+		if !stopped {
+			stopped = true
+			close(stopChan)
+		}
+	}
+	return done, stop, nil
+}
+
+// forwardedPorts translates LOCAL:REMOTE specs into the forwarder format,
+// resolving named remote ports and expanding AutoPortForward.
+func forwardedPorts(pod *corev1.Pod, specs []string) ([]string, error) {
+	var ports []string
+	for _, spec := range specs {
+		if spec == AutoPortForward {
+			for _, container := range pod.Spec.Containers {
+				for _, port := range container.Ports {
+					if port.Protocol == "" || port.Protocol == corev1.ProtocolTCP {
+						ports = append(ports, fmt.Sprintf(":%d", port.ContainerPort))
+					}
+				}
+			}
+			continue
+		}
+
+		local, remote, found := strings.Cut(spec, ":")
+		if !found {
+			local, remote = spec, spec
+		}
+		remotePort, err := containerPort(pod, remote)
+		if err != nil {
+			return nil, err
+		}
+		if !found && remote != strconv.Itoa(int(remotePort)) {
+			// a bare port name listens on a random local port
+			local = ""
+		}
+		ports = append(ports, fmt.Sprintf("%s:%d", local, remotePort))
+	}
+	if len(ports) == 0 {
+		return nil, fmt.Errorf("pod %q declares no TCP container ports to forward", pod.Name)
+	}
+	return ports, nil
+}
+
+// containerPort resolves port, a number or the name of a container port of pod
+func containerPort(pod *corev1.Pod, port string) (int32, error) {
+	if number, err := strconv.ParseUint(port, 10, 16); err == nil {
+		return int32(number), nil
+	}
+	for _, container := range pod.Spec.Containers {
+		for _, p := range container.Ports {
+			if p.Name == port {
+				return p.ContainerPort, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("pod %q has no container port named %q", pod.Name, port)
+}
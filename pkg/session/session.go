@@ -0,0 +1,252 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"dup/pkg/duplicate"
+
+	"github.com/google/shlex"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/cli-runtime/pkg/genericiooptions"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/kubernetes"
+	restclient "k8s.io/client-go/rest"
+	"k8s.io/kubectl/pkg/cmd/attach"
+	"k8s.io/kubectl/pkg/cmd/exec"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"k8s.io/kubectl/pkg/cmd/util/podcmd"
+	"k8s.io/kubectl/pkg/util/interrupt"
+)
+
+// DefaultRemoveTTL expires duplicates created with --rm, in case the client
+// dies before it can delete them.
+const DefaultRemoveTTL = 12 * time.Hour
+
+// ExecShell is the value of --exec when no command is given, it opens bash
+// in the container, or sh when bash is not installed. It is not a command so
+// that an explicit --exec=sh runs sh.
+const ExecShell = "<shell>"
+
+var defaultShell = []string{"sh", "-c", "command -v bash >/dev/null 2>&1 && exec bash || exec sh"}
+
+// Options contains the options for interacting with a duplicate once it was created.
+type Options struct {
+	Wait        bool
+	Exec        string
+	Attach      bool
+	Logs        bool
+	Remove      bool
+	PortForward []string
+	Container   string
+	WaitTimeout time.Duration
+
+	namespace string
+	client    kubernetes.Interface
+	config    *restclient.Config
+
+	genericiooptions.IOStreams
+}
+
+// NewOptions returns an initialized Options instance
+func NewOptions(ioStreams genericiooptions.IOStreams) *Options {
+	return &Options{
+		WaitTimeout: 5 * time.Minute,
+		IOStreams:   ioStreams,
+	}
+}
+
+// Enabled returns whether anything has to happen after creation
+func (o *Options) Enabled() bool {
+	return o.Wait || o.interactive() || len(o.PortForward) > 0
+}
+
+// interactive returns whether a command takes over the terminal
+func (o *Options) interactive() bool {
+	return len(o.Exec) > 0 || o.Attach || o.Logs
+}
+
+// Complete completes all the required options
+func (o *Options) Complete(f cmdutil.Factory, namespace string) error {
+	var err error
+
+	if !o.Enabled() {
+		return nil
+	}
+	o.namespace = namespace
+	o.config, err = f.ToRESTConfig()
+	if err != nil {
+		return err
+	}
+	o.client, err = f.KubernetesClientSet()
+	return err
+}
+
+// Validate checks that at most one command takes over the terminal
+func (o *Options) Validate() error {
+	interactive := 0
+	for _, set := range []bool{len(o.Exec) > 0, o.Attach, o.Logs} {
+		if set {
+			interactive++
+		}
+	}
+	if interactive > 1 {
+		return fmt.Errorf("only one of --exec, --attach and --logs may be used")
+	}
+	if o.Remove && len(o.Exec) == 0 && !o.Attach && len(o.PortForward) == 0 {
+		return fmt.Errorf("--rm should only be used with --exec, --attach or --port-forward")
+	}
+	return nil
+}
+
+// Run waits for the pod of the created duplicates to become ready, starts
+// port forwarding, then execs, attaches or follows its logs as requested.
+// With Remove, everything created is deleted once the session ends or the
+// process is interrupted.
+func (o *Options) Run(group string, created []*resource.Info) error {
+	ctx := context.Background()
+	// interactive sessions leave signals to the terminal handling of kubectl
+	if o.Remove || !o.interactive() {
+		var stop context.CancelFunc
+		ctx, stop = signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+		defer stop()
+	}
+	if !o.Remove {
+		return o.run(ctx, group, created, nil)
+	}
+
+	handler, cleanup := o.cleanupHandler(group, created)
+	defer cleanup()
+	go func() {
+		<-ctx.Done()
+		cleanup()
+	}()
+	return o.run(ctx, group, created, handler)
+}
+
+func (o *Options) run(ctx context.Context, group string, created []*resource.Info, interruptParent *interrupt.Handler) error {
+	listOptions, prefixes, err := podSelector(group, created)
+	if err != nil {
+		return err
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, o.WaitTimeout)
+	defer cancel()
+	eventsCtx, stopEvents := context.WithCancel(waitCtx)
+	go streamEvents(eventsCtx, o.client, o.namespace, prefixes, time.Now().Truncate(time.Second), o.ErrOut)
+	pod, err := waitForPod(waitCtx, o.client, o.namespace, listOptions, o.ErrOut)
+	stopEvents()
+	if err != nil {
+		return err
+	}
+
+	var forwarded <-chan error
+	if len(o.PortForward) > 0 {
+		var stopForwarding func()
+		forwarded, stopForwarding, err = o.portForward(pod)
+		if err != nil {
+			return err
+		}
+		defer stopForwarding()
+	}
+
+	container, err := podcmd.FindOrDefaultContainerByName(pod, o.Container, false, o.ErrOut)
+	if err != nil {
+		return err
+	}
+	switch {
+	case len(o.Exec) > 0:
+		return o.exec(pod, container, interruptParent)
+	case o.Attach:
+		return o.attach(pod, container, interruptParent)
+	case o.Logs:
+		return o.logs(ctx, pod, container)
+	case forwarded != nil:
+		fmt.Fprintln(o.ErrOut, "Press Ctrl+C to stop forwarding.")
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-forwarded:
+			return err
+		}
+	}
+	return nil
+}
+
+// podSelector selects the pod to interact with: a duplicated Pod if there is
+// one, otherwise the pods of the first duplicated workload.
+func podSelector(group string, created []*resource.Info) (metav1.ListOptions, []string, error) {
+	var prefixes []string
+	for _, info := range created {
+		prefixes = append(prefixes, info.Name)
+	}
+	for _, info := range created {
+		if info.Mapping.GroupVersionKind.Kind == "Pod" {
+			return metav1.ListOptions{FieldSelector: fields.OneTermEqualSelector("metadata.name", info.Name).String()}, prefixes, nil
+		}
+	}
+	if len(group) == 0 || len(created) == 0 {
+		return metav1.ListOptions{}, nil, fmt.Errorf("no duplicated pod to wait for")
+	}
+	return metav1.ListOptions{LabelSelector: duplicate.GroupLabel + "=" + group}, prefixes, nil
+}
+
+func (o *Options) exec(pod *corev1.Pod, container *corev1.Container, interruptParent *interrupt.Handler) error {
+	command := defaultShell
+	if o.Exec != ExecShell {
+		var err error
+		command, err = shlex.Split(o.Exec)
+		if err != nil {
+			return fmt.Errorf("invalid --exec command %q: %v", o.Exec, err)
+		}
+	}
+	options := &exec.ExecOptions{
+		StreamOptions: exec.StreamOptions{
+			Namespace:       pod.Namespace,
+			PodName:         pod.Name,
+			ContainerName:   container.Name,
+			Stdin:           true,
+			TTY:             true,
+			InterruptParent: interruptParent,
+			IOStreams:       o.IOStreams,
+		},
+		Command:   command,
+		Executor:  &exec.DefaultRemoteExecutor{},
+		PodClient: o.client.CoreV1(),
+		Config:    o.config,
+	}
+	return options.Run()
+}
+
+func (o *Options) attach(pod *corev1.Pod, container *corev1.Container, interruptParent *interrupt.Handler) error {
+	options := attach.NewAttachOptions(o.IOStreams)
+	options.Namespace = pod.Namespace
+	options.PodName = pod.Name
+	options.ContainerName = container.Name
+	options.Pod = pod
+	options.Stdin = container.Stdin
+	options.TTY = container.TTY
+	options.InterruptParent = interruptParent
+	options.CommandName = "kubectl attach"
+	options.Config = o.config
+	return options.Run()
+}
+
+func (o *Options) logs(ctx context.Context, pod *corev1.Pod, container *corev1.Container) error {
+	stream, err := o.client.CoreV1().Pods(pod.Namespace).
+		GetLogs(pod.Name, &corev1.PodLogOptions{Container: container.Name, Follow: true}).
+		Stream(ctx)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+	_, err = io.Copy(o.Out, stream)
+	return err
+}
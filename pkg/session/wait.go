@@ -0,0 +1,137 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	watchtools "k8s.io/client-go/tools/watch"
+	"k8s.io/kubectl/pkg/util/podutils"
+)
+
+// waitForPod waits until the first pod matching listOptions is Ready,
+// printing its progress to out. It fails if the pod terminates before.
+func waitForPod(ctx context.Context, client kubernetes.Interface, namespace string, listOptions metav1.ListOptions, out io.Writer) (*corev1.Pod, error) {
+	lw := &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			options.LabelSelector = listOptions.LabelSelector
+			options.FieldSelector = listOptions.FieldSelector
+			return client.CoreV1().Pods(namespace).List(ctx, options)
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			options.LabelSelector = listOptions.LabelSelector
+			options.FieldSelector = listOptions.FieldSelector
+			return client.CoreV1().Pods(namespace).Watch(ctx, options)
+		},
+	}
+
+	var (
+		target   string
+		progress string
+	)
+	event, err := watchtools.UntilWithSync(ctx, lw, &corev1.Pod{}, nil, func(event watch.Event) (bool, error) {
+		pod, ok := event.Object.(*corev1.Pod)
+		if !ok {
+			return false, nil
+		}
+		// stick to the oldest pod, replicas beyond the first are ignored
+		if len(target) == 0 {
+			target = pod.Name
+		}
+		if pod.Name != target {
+			return false, nil
+		}
+		if event.Type == watch.Deleted {
+			return false, fmt.Errorf("pod %q was deleted while waiting for it", pod.Name)
+		}
+
+		if current := podProgress(pod); current != progress {
+			progress = current
+			fmt.Fprintf(out, "pod/%s: %s\n", pod.Name, progress)
+		}
+		switch pod.Status.Phase {
+		case corev1.PodSucceeded, corev1.PodFailed:
+			return false, fmt.Errorf("pod %q terminated before becoming ready, phase is %s", pod.Name, pod.Status.Phase)
+		}
+		return podutils.IsPodReady(pod), nil
+	})
+	if err != nil {
+		switch ctx.Err() {
+		case context.DeadlineExceeded:
+			return nil, fmt.Errorf("timed out waiting for the duplicate pod to become ready")
+		case context.Canceled:
+			return nil, fmt.Errorf("interrupted while waiting for the duplicate pod to become ready")
+		}
+		return nil, err
+	}
+	return event.Object.(*corev1.Pod), nil
+}
+
+// podProgress describes the readiness of pod in a single line
+func podProgress(pod *corev1.Pod) string {
+	ready := 0
+	var waiting []string
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Ready {
+			ready++
+		}
+		if status.State.Waiting != nil && len(status.State.Waiting.Reason) > 0 {
+			waiting = append(waiting, fmt.Sprintf("%s: %s", status.Name, status.State.Waiting.Reason))
+		}
+	}
+	progress := fmt.Sprintf("%s, %d/%d containers ready", pod.Status.Phase, ready, len(pod.Spec.Containers))
+	if len(waiting) > 0 {
+		progress += " (" + strings.Join(waiting, ", ") + ")"
+	}
+	return progress
+}
+
+// streamEvents prints the events of objects whose name starts with one of
+// prefixes until ctx is done. Duplicates have a random name suffix, so their
+// children (ReplicaSets, Pods) are matched by prefix.
+func streamEvents(ctx context.Context, client kubernetes.Interface, namespace string, prefixes []string, since time.Time, out io.Writer) {
+	watcher, err := client.CoreV1().Events(namespace).Watch(ctx, metav1.ListOptions{})
+	if err != nil {
+		fmt.Fprintf(out, "warning: unable to watch events: %v\n", err)
+		return
+	}
+	defer watcher.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e, ok := <-watcher.ResultChan():
+			if !ok {
+				return
+			}
+			event, ok := e.Object.(*corev1.Event)
+			if !ok || e.Type == watch.Deleted || eventTime(event).Before(since) {
+				continue
+			}
+			for _, prefix := range prefixes {
+				if strings.HasPrefix(event.InvolvedObject.Name, prefix) {
+					fmt.Fprintf(out, "  %s/%s\t%s\t%s\n", strings.ToLower(event.InvolvedObject.Kind), event.InvolvedObject.Name, event.Reason, strings.TrimSpace(event.Message))
+					break
+				}
+			}
+		}
+	}
+}
+
+func eventTime(event *corev1.Event) time.Time {
+	switch {
+	case !event.LastTimestamp.IsZero():
+		return event.LastTimestamp.Time
+	case !event.EventTime.IsZero():
+		return event.EventTime.Time
+	}
+	return event.CreationTimestamp.Time
+}
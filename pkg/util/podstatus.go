@@ -0,0 +1,139 @@
+package util
+
+import (
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/duration"
+)
+
+// PodStatus summarizes the state of a pod the way the STATUS column of
+// 'kubectl get pods' does, e.g. Running, Completed or CrashLoopBackOff.
+func PodStatus(pod *corev1.Pod) string {
+	reason := string(pod.Status.Phase)
+	if len(pod.Status.Reason) > 0 {
+		reason = pod.Status.Reason
+	}
+
+	for i := len(pod.Status.InitContainerStatuses) - 1; i >= 0; i-- {
+		status := pod.Status.InitContainerStatuses[i]
+		switch {
+		case status.State.Terminated != nil && status.State.Terminated.ExitCode == 0:
+			continue
+		case status.State.Terminated != nil:
+			if len(status.State.Terminated.Reason) == 0 {
+				return fmt.Sprintf("Init:ExitCode:%d", status.State.Terminated.ExitCode)
+			}
+			return "Init:" + status.State.Terminated.Reason
+		case status.State.Waiting != nil && len(status.State.Waiting.Reason) > 0 && status.State.Waiting.Reason != "PodInitializing":
+			return "Init:" + status.State.Waiting.Reason
+		case status.State.Running != nil || status.State.Waiting != nil:
+			return fmt.Sprintf("Init:%d/%d", i, len(pod.Spec.InitContainers))
+		}
+	}
+
+	hasRunning := false
+	for i := len(pod.Status.ContainerStatuses) - 1; i >= 0; i-- {
+		status := pod.Status.ContainerStatuses[i]
+		switch {
+		case status.State.Waiting != nil && len(status.State.Waiting.Reason) > 0:
+			reason = status.State.Waiting.Reason
+		case status.State.Terminated != nil && len(status.State.Terminated.Reason) > 0:
+			reason = status.State.Terminated.Reason
+		case status.State.Terminated != nil && status.State.Terminated.Signal != 0:
+			reason = fmt.Sprintf("Signal:%d", status.State.Terminated.Signal)
+		case status.State.Terminated != nil:
+			reason = fmt.Sprintf("ExitCode:%d", status.State.Terminated.ExitCode)
+		case status.Ready && status.State.Running != nil:
+			hasRunning = true
+		}
+	}
+	if reason == "Completed" && hasRunning {
+		reason = string(corev1.PodRunning)
+	}
+
+	if pod.DeletionTimestamp != nil {
+		if pod.Status.Reason == "NodeLost" {
+			return "Unknown"
+		}
+		return "Terminating"
+	}
+	return reason
+}
+
+// LastTermination describes the most recent termination of a container of
+// pod, with its exit code and reason, or returns "" when none terminated.
+func LastTermination(pod *corev1.Pod, now time.Time) string {
+	var (
+		last     *corev1.ContainerStateTerminated
+		name     string
+		restarts int32
+	)
+	for _, status := range append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...) {
+		restarts += status.RestartCount
+		terminated := status.State.Terminated
+		if terminated == nil {
+			terminated = status.LastTerminationState.Terminated
+		}
+		if terminated != nil && (last == nil || terminated.FinishedAt.After(last.FinishedAt.Time)) {
+			last, name = terminated, status.Name
+		}
+	}
+	if last == nil {
+		return ""
+	}
+	description := fmt.Sprintf("container %s last exited with code %d", name, last.ExitCode)
+	if len(last.Reason) > 0 {
+		description += fmt.Sprintf(" (%s)", last.Reason)
+	}
+	if !last.FinishedAt.IsZero() {
+		description += fmt.Sprintf(" %s ago", duration.HumanDuration(now.Sub(last.FinishedAt.Time)))
+	}
+	if restarts > 0 {
+		description += fmt.Sprintf(", %d restarts", restarts)
+	}
+	return description
+}
+
+// WorkloadStatus summarizes the pods of u, or the status of u itself when it is a Pod
+func WorkloadStatus(u *unstructured.Unstructured) string {
+	nestedInt := func(fields ...string) int64 {
+		value, _, _ := unstructured.NestedInt64(u.Object, fields...)
+		return value
+	}
+	switch u.GetKind() {
+	case "Pod":
+		pod := &corev1.Pod{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, pod); err != nil {
+			return "<unknown>"
+		}
+		return PodStatus(pod)
+	case "Deployment", "StatefulSet", "ReplicaSet", "ReplicationController":
+		replicas, found, _ := unstructured.NestedInt64(u.Object, "spec", "replicas")
+		if !found {
+			replicas = 1
+		}
+		return fmt.Sprintf("%d/%d ready", nestedInt("status", "readyReplicas"), replicas)
+	case "DaemonSet":
+		return fmt.Sprintf("%d/%d ready", nestedInt("status", "numberReady"), nestedInt("status", "desiredNumberScheduled"))
+	case "Job":
+		conditions, _, _ := unstructured.NestedSlice(u.Object, "status", "conditions")
+		for _, c := range conditions {
+			condition, _ := c.(map[string]interface{})
+			if condition["status"] == "True" && (condition["type"] == "Complete" || condition["type"] == "Failed") {
+				return condition["type"].(string)
+			}
+		}
+		return fmt.Sprintf("%d active", nestedInt("status", "active"))
+	case "CronJob":
+		if suspended, _, _ := unstructured.NestedBool(u.Object, "spec", "suspend"); suspended {
+			return "Suspended"
+		}
+		active, _, _ := unstructured.NestedSlice(u.Object, "status", "active")
+		return fmt.Sprintf("%d active", len(active))
+	}
+	return "<none>"
+}
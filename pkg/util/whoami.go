@@ -0,0 +1,34 @@
+package util
+
+import (
+	"context"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+)
+
+// WhoAmI returns the name of the user the API server authenticates us as.
+// Servers without the SelfSubjectReview API fall back to the kubeconfig user.
+func WhoAmI(f cmdutil.Factory) string {
+	client, err := f.KubernetesClientSet()
+	if err == nil {
+		review, err := client.AuthenticationV1().
+			SelfSubjectReviews().
+			Create(context.TODO(), &authenticationv1.SelfSubjectReview{}, metav1.CreateOptions{})
+		if err == nil && len(review.Status.UserInfo.Username) > 0 {
+			return review.Status.UserInfo.Username
+		}
+		klog.V(4).Infof("SelfSubjectReview failed, using kubeconfig user: %v", err)
+	}
+
+	rawConfig, err := f.ToRawKubeConfigLoader().RawConfig()
+	if err != nil {
+		return ""
+	}
+	if ctx, ok := rawConfig.Contexts[rawConfig.CurrentContext]; ok {
+		return ctx.AuthInfo
+	}
+	return ""
+}